@@ -2,8 +2,6 @@ package log
 
 import (
 	"fmt"
-	"os"
-	"sync/atomic"
 	"time"
 
 	"github.com/derision-test/glock"
@@ -13,41 +11,141 @@ type logSink interface {
 	Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error
 }
 
+// syncer is implemented by log sinks that buffer output.
+type syncer interface {
+	Sync() error
+}
+
+// closer is implemented by log sinks that hold connections or run background
+// goroutines.
+type closer interface {
+	Close() error
+}
+
+// timestampFormatter is implemented by loggers that format timestamps embedded in
+// field values with the configured timestamp format and timezone.
+type timestampFormatter interface {
+	formatTimestamp(t time.Time) interface{}
+}
+
+// sequencedLogger is implemented by loggers that report the sequence number
+// assigned to each message by the underlying base logger. If journal is true, the
+// message is recorded for a later replay or rollup and is assigned a sequence
+// number even if its level is disabled.
+type sequencedLogger interface {
+	logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64
+}
+
+// configuredLogger is implemented by loggers backed by a base logger. It exposes
+// the configuration of the base logger so that derived loggers can share it.
+type configuredLogger interface {
+	config() *baseWrapper
+}
+
+// orderedSink is implemented by log sinks that write fields in a configurable
+// order. The given keys list every field in the order in which it is written.
+type orderedSink interface {
+	LogOrdered(timestamp time.Time, level LogLevel, fields LogFields, keys []string, msg string) error
+}
+
+// nativeTimeSink is implemented by log sinks that encode time values natively.
+// Time values in the fields of messages sent to such sinks are not formatted.
+type nativeTimeSink interface {
+	encodesNativeTimes() bool
+}
+
+func encodesNativeTimes(sink logSink) bool {
+	n, ok := sink.(nativeTimeSink)
+	return ok && n.encodesNativeTimes()
+}
+
+// FieldOriginalSequence is a field assigned to a message that has
+// been replayed or rolled up. Its value is equal to the sequence number
+// assigned to the message when it was originally logged.
+const FieldOriginalSequence = "original-sequence"
+
+const fieldSequenceNumber = "sequenceNumber"
+
 type baseWrapper struct {
-	logSink  logSink
-	level    LogLevel
-	clock    glock.Clock
-	exiter   func()
-	sequence uint64
+	logSink          logSink
+	level            LogLevel
+	clock            glock.Clock
+	exiter           func()
+	fatalHooks       []FatalHook
+	fatalHookTimeout time.Duration
+	sequence         func() uint64
+	timestamps       timestampOptions
+	order            fieldOrder
+	nativeTimes      bool
+}
+
+// timestampOptions controls how messages are timestamped and how time values in
+// their fields are formatted.
+type timestampOptions struct {
+	// useOriginal assigns replayed and rolled up messages the time at which they
+	// were originally logged.
+	useOriginal bool
+
+	// location is the timezone of message timestamps. A nil location selects UTC.
+	location *time.Location
+
+	// fieldFormat formats time values in fields. A nil format selects JSONTimeFormat
+	// and leaves the timezone of the value unchanged.
+	fieldFormat *timestampFormat
+
+	// format is the configured timestamp format, used for timestamps embedded in
+	// field values such as replay bundles. A nil format selects JSONTimeFormat.
+	format *timestampFormat
+}
+
+func (o timestampOptions) timezone() *time.Location {
+	if o.location == nil {
+		return time.UTC
+	}
+
+	return o.location
 }
 
 type baseLogger struct {
 	wrapper *baseWrapper
 	fields  LogFields
+	keys    []string
 }
 
-func newBaseLogger(logSink logSink, level LogLevel, initialFields LogFields) Logger {
+func newBaseLogger(logSink logSink, level LogLevel, initialFields LogFields, timestamps timestampOptions, order fieldOrder, options *loggerOptions) Logger {
 	wrapper := &baseWrapper{
-		logSink,
-		level,
-		glock.NewRealClock(),
-		func() { os.Exit(1) },
-		0,
+		logSink:          logSink,
+		level:            level,
+		clock:            options.clock,
+		exiter:           options.exiter,
+		fatalHooks:       options.fatalHooks,
+		fatalHookTimeout: options.fatalHookTimeout,
+		sequence:         options.sequence,
+		timestamps:       timestamps,
+		order:            order,
+		nativeTimes:      encodesNativeTimes(logSink),
 	}
 
-	return FromMinimalLogger(&baseLogger{wrapper, initialFields})
+	var keys []string
+	if order.insertion {
+		keys = appendFieldKeys(nil, initialFields)
+	}
+
+	return FromMinimalLogger(&baseLogger{wrapper, initialFields, keys})
 }
 
 func newTestLogger(logSink logSink, level LogLevel, initialFields LogFields, clock glock.Clock, exiter func()) Logger {
 	wrapper := &baseWrapper{
-		logSink,
-		level,
-		clock,
-		exiter,
-		0,
+		logSink:          logSink,
+		level:            level,
+		clock:            clock,
+		exiter:           exiter,
+		fatalHookTimeout: DefaultFatalHookTimeout,
+		sequence:         newSequenceCounter(),
+		nativeTimes:      encodesNativeTimes(logSink),
 	}
 
-	return FromMinimalLogger(&baseLogger{wrapper, initialFields})
+	return FromMinimalLogger(&baseLogger{wrapper, initialFields, nil})
 }
 
 func (s *baseLogger) WithFields(fields LogFields) MinimalLogger {
@@ -55,30 +153,164 @@ func (s *baseLogger) WithFields(fields LogFields) MinimalLogger {
 		return s
 	}
 
-	return &baseLogger{s.wrapper, s.fields.concat(fields)}
+	keys := s.keys
+	if s.wrapper.order.insertion {
+		keys = appendFieldKeys(keys, fields)
+	}
+
+	return &baseLogger{s.wrapper, s.fields.concat(fields), keys}
 }
 
 func (s *baseLogger) LogWithFields(level LogLevel, fields LogFields, format string, args ...interface{}) {
-	if level > s.wrapper.level {
+	s.logSequenced(level, fields, false, format, args...)
+}
+
+// logSequenced logs the message if its level is enabled and returns the sequence
+// number assigned to it. Only messages that are written or journaled are assigned a
+// sequence number, so that the sequence numbers of written messages have no gaps
+// unless journaled messages are filtered out.
+func (s *baseLogger) logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
+	var seq uint64
+	if enabled := level.Enabled(s.wrapper.level); enabled || journal {
+		seq = s.wrapper.sequence()
+
+		if enabled {
+			s.write(level, fields, seq, format, args...)
+		}
+	}
+
+	switch level {
+	case LevelFatal:
+		s.Sync()
+		hooks := append(append([]FatalHook{}, s.wrapper.fatalHooks...), registeredFatalHooks()...)
+		runFatalHooks(hooks, s.wrapper.fatalHookTimeout)
+		s.wrapper.exiter()
+
+	case LevelPanic:
+		s.Sync()
+		panic(fmt.Sprintf(format, args...))
+	}
+
+	return seq
+}
+
+// write timestamps the message and sends it to the sink.
+func (s *baseLogger) write(level LogLevel, fields LogFields, seq uint64, format string, args ...interface{}) {
+	timestamp := s.wrapper.clock.Now()
+	if s.wrapper.timestamps.useOriginal {
+		if original, ok := originalTimestamp(fields); ok {
+			timestamp = original
+		}
+	}
+	timestamp = timestamp.In(s.wrapper.timestamps.timezone())
+
+	if !s.wrapper.nativeTimes {
+		if format := s.wrapper.timestamps.fieldFormat; format != nil {
+			fields = fields.formatTimeValues(*format, s.wrapper.timestamps.timezone())
+		} else {
+			fields = fields.normalizeTimeValues()
+		}
+	}
+
+	s.log(timestamp, level, fields, seq, fmt.Sprintf(format, args...))
+}
+
+// log sends the message to the sink. Sinks that support ordering receive the keys
+// of the merged fields in the configured order.
+func (s *baseLogger) log(timestamp time.Time, level LogLevel, fields LogFields, seq uint64, message string) {
+	merged := s.fields.concat(fields)
+	merged[fieldSequenceNumber] = seq
+
+	if ordered, ok := s.wrapper.logSink.(orderedSink); ok && !s.wrapper.order.isDefault() {
+		keys := s.keys
+		if s.wrapper.order.insertion {
+			keys = appendFieldKeys(keys, fields)
+		}
+
+		ordered.LogOrdered(timestamp, level, merged, s.wrapper.order.keys(merged, keys), message)
 		return
 	}
 
-	seq := atomic.AddUint64(&s.wrapper.sequence, 1)
-	fields = fields.normalizeTimeValues()
-	fields["sequenceNumber"] = seq
+	s.wrapper.logSink.Log(timestamp, level, merged, message)
+}
 
-	s.wrapper.logSink.Log(
-		s.wrapper.clock.Now().UTC(),
-		level,
-		s.fields.concat(fields),
-		fmt.Sprintf(format, args...),
-	)
+func (s *baseLogger) config() *baseWrapper {
+	return s.wrapper
+}
 
-	if level == LevelFatal {
-		s.wrapper.exiter()
+func (s *baseLogger) formatTimestamp(t time.Time) interface{} {
+	t = t.In(s.wrapper.timestamps.timezone())
+	if format := s.wrapper.timestamps.format; format != nil {
+		return format.value(t)
 	}
+
+	return t.Format(JSONTimeFormat)
 }
 
 func (s *baseLogger) Sync() error {
+	if syncer, ok := s.wrapper.logSink.(syncer); ok {
+		return syncer.Sync()
+	}
+
 	return nil
 }
+
+// Close flushes buffered messages, then closes the connections and stops the
+// background goroutines of the log output. The logger and every logger derived
+// from it must not be used afterwards.
+func (s *baseLogger) Close() error {
+	err := s.Sync()
+
+	if closer, ok := s.wrapper.logSink.(closer); ok {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// originalTimestamp returns the time at which a replayed or rolled up message
+// was originally logged.
+func originalTimestamp(fields LogFields) (time.Time, bool) {
+	for _, key := range []string{FieldReplayTime, FieldRollupTime} {
+		if timestamp, ok := fields[key].(time.Time); ok {
+			return timestamp.UTC(), true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// formatTimestamp formats a timestamp embedded in a field value with the timestamp
+// format and timezone of the given logger. Timestamps are formatted with
+// JSONTimeFormat if the logger does not implement timestampFormatter.
+func formatTimestamp(logger interface{}, t time.Time) interface{} {
+	if formatter, ok := logger.(timestampFormatter); ok {
+		return formatter.formatTimestamp(t)
+	}
+
+	return t.Format(JSONTimeFormat)
+}
+
+// loggerConfig returns the configuration of the base logger backing the given
+// logger, or nil if the logger is not backed by a base logger.
+func loggerConfig(logger interface{}) *baseWrapper {
+	if configured, ok := logger.(configuredLogger); ok {
+		return configured.config()
+	}
+
+	return nil
+}
+
+// logSequenced logs a message with the given logger and returns the sequence number
+// assigned to it by the underlying base logger. Zero is returned if the logger does
+// not assign sequence numbers, or if the message was held back by a rollup logger.
+func logSequenced(logger Logger, level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
+	if sequenced, ok := logger.(sequencedLogger); ok {
+		return sequenced.logSequenced(level, fields, journal, format, args...)
+	}
+
+	logger.LogWithFields(level, fields, format, args...)
+	return 0
+}
@@ -84,3 +84,37 @@ func TestBaseLoggerLogFatal(t *testing.T) {
 	logger.LogWithFields(LevelFatal, nil, "test %d %d %d", 1, 2, 3)
 	assert.True(t, called)
 }
+
+type closingLogSink struct {
+	*MockLogSink
+	closed int
+}
+
+func (s *closingLogSink) Close() error {
+	s.closed++
+	return nil
+}
+
+func TestBaseLoggerClose(t *testing.T) {
+	sink := &closingLogSink{MockLogSink: NewMockLogSink()}
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	assert.Nil(t, Close(NewReplayLogger(logger.WithFields(LogFields{"a": 1}), LevelDebug)))
+	assert.Equal(t, 1, sink.closed)
+
+	assert.Nil(t, Close(NewNilLogger()))
+}
+
+func TestBaseLoggerSequenceSkipsFilteredMessages(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelInfo, nil, glock.NewMockClock(), func() {})
+
+	logger.Info("a")
+	logger.Debug("filtered")
+	logger.Info("b")
+
+	history := sink.LogFunc.History()
+	assert.Len(t, history, 2)
+	assert.Equal(t, uint64(1), history[0].Arg2["sequenceNumber"])
+	assert.Equal(t, uint64(2), history[1].Arg2["sequenceNumber"])
+}
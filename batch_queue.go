@@ -0,0 +1,281 @@
+package log
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+const (
+	batchMaxEntries     = 512
+	batchMaxBytes       = 1 << 20
+	batchFlushInterval  = time.Second
+	batchMaxRetries     = 3
+	batchInitialBackoff = time.Millisecond * 100
+)
+
+// batchEntry is a single encoded message. Entries with the same key may be sent
+// together in a single request.
+type batchEntry struct {
+	key  string
+	data []byte
+}
+
+// batchSendError is returned by a send function that sent some entries of a batch
+// before failing. Only the unsent entries are retried.
+type batchSendError struct {
+	err    error
+	unsent []batchEntry
+}
+
+func (e *batchSendError) Error() string {
+	return e.err.Error()
+}
+
+func (e *batchSendError) Unwrap() error {
+	return e.err
+}
+
+// batchQueue buffers encoded messages and sends them in batches from a background
+// goroutine. A batch is sent once it reaches the maximum number of entries or bytes,
+// once the flush interval elapses, or when Sync is called. Failed batches are retried
+// with exponential backoff before being dropped. When the buffer is full, new messages
+// are dropped. Dropped messages are reported after the next successful send.
+type batchQueue struct {
+	send          func(entries []batchEntry) error
+	clock         glock.Clock
+	maxEntries    int
+	maxBytes      int
+	maxPending    int
+	flushInterval time.Duration
+	maxRetries    int
+	flushTimeout  time.Duration
+	onDrop        func(dropped int)
+	closeOnce     sync.Once
+	mutex         sync.Mutex
+	pending       []batchEntry
+	pendingBytes  int
+	dropped       int
+	sending       bool
+	waiters       []chan struct{}
+	signal        chan struct{}
+	flushes       chan struct{}
+	done          chan struct{}
+	stopped       chan struct{}
+}
+
+func newBatchQueue(c *Config, clock glock.Clock, send func(entries []batchEntry) error) *batchQueue {
+	q := &batchQueue{
+		send:          send,
+		clock:         clock,
+		maxEntries:    positiveOrDefault(c.LogBatchMaxEntries, batchMaxEntries),
+		maxBytes:      positiveOrDefault(c.LogBatchMaxBytes, batchMaxBytes),
+		maxPending:    positiveOrDefault(c.LogOutputBufferSize, streamBufferSize),
+		flushInterval: time.Duration(positiveOrDefault(c.LogBatchFlushIntervalMillis, int(batchFlushInterval/time.Millisecond))) * time.Millisecond,
+		maxRetries:    retriesOrDefault(c.LogBatchMaxRetries, batchMaxRetries),
+		flushTimeout:  DefaultOutputFlushTimeout,
+		signal:        make(chan struct{}, 1),
+		flushes:       make(chan struct{}, 1),
+		done:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+
+	go q.run()
+	return q
+}
+
+func positiveOrDefault(value, defaultValue int) int {
+	if value <= 0 {
+		return defaultValue
+	}
+
+	return value
+}
+
+// retriesOrDefault returns the default if value is zero. A negative value disables
+// retries.
+func retriesOrDefault(value, defaultValue int) int {
+	if value == 0 {
+		return defaultValue
+	}
+
+	if value < 0 {
+		return 0
+	}
+
+	return value
+}
+
+// Add enqueues an entry. Add never blocks on the network.
+func (q *batchQueue) Add(key string, data []byte) {
+	q.mutex.Lock()
+	full := false
+	if len(q.pending) < q.maxPending {
+		q.pending = append(q.pending, batchEntry{key: key, data: data})
+		q.pendingBytes += len(data)
+		full = q.full()
+	} else {
+		q.dropped++
+	}
+	q.mutex.Unlock()
+
+	if full {
+		notify(q.signal)
+	}
+}
+
+func (q *batchQueue) full() bool {
+	return len(q.pending) >= q.maxEntries || q.pendingBytes >= q.maxBytes
+}
+
+// Sync blocks until all buffered entries have been sent (or dropped) or the flush
+// timeout elapses.
+func (q *batchQueue) Sync() error {
+	q.mutex.Lock()
+	if len(q.pending) == 0 && !q.sending {
+		q.mutex.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	q.waiters = append(q.waiters, ch)
+	q.mutex.Unlock()
+	notify(q.flushes)
+
+	select {
+	case <-ch:
+		return nil
+	case <-q.clock.After(q.flushTimeout):
+		return ErrOutputFlushTimeout
+	}
+}
+
+// Close sends all buffered entries and stops the background goroutine.
+func (q *batchQueue) Close() error {
+	q.closeOnce.Do(func() { close(q.done) })
+	<-q.stopped
+	return nil
+}
+
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+func (q *batchQueue) run() {
+	defer close(q.stopped)
+
+	for {
+		select {
+		case <-q.signal:
+			q.flush(false)
+		case <-q.flushes:
+			q.flush(true)
+		case <-q.clock.After(q.flushInterval):
+			q.flush(true)
+		case <-q.done:
+			q.flush(true)
+			return
+		}
+	}
+}
+
+// flush sends batches until the queue is empty, then releases goroutines blocked
+// in Sync. If all is false, only full batches are sent.
+func (q *batchQueue) flush(all bool) {
+	for {
+		batch, dropped := q.take(all)
+		if len(batch) == 0 {
+			return
+		}
+
+		if unsent, err := q.sendWithRetries(batch); err != nil {
+			q.mutex.Lock()
+			q.dropped += dropped + len(unsent)
+			q.mutex.Unlock()
+			continue
+		}
+
+		if dropped > 0 && q.onDrop != nil {
+			q.onDrop(dropped)
+		}
+	}
+}
+
+// take removes the next batch from the queue along with the number of entries
+// dropped since the last successful send. If all is false, a batch is returned
+// only if it is full. If the queue is empty, any goroutines blocked in Sync are
+// released.
+func (q *batchQueue) take(all bool) ([]batchEntry, int) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !all && len(q.pending) > 0 && !q.full() {
+		q.sending = false
+		return nil, 0
+	}
+
+	n, size := 0, 0
+	for n < len(q.pending) && n < q.maxEntries {
+		if n > 0 && size+len(q.pending[n].data) > q.maxBytes {
+			break
+		}
+
+		size += len(q.pending[n].data)
+		n++
+	}
+
+	batch := q.pending[:n:n]
+	q.pending = q.pending[n:]
+	q.pendingBytes -= size
+	q.sending = n > 0
+
+	dropped := 0
+	if n > 0 {
+		dropped, q.dropped = q.dropped, 0
+	} else {
+		q.pending = nil
+
+		for _, ch := range q.waiters {
+			close(ch)
+		}
+
+		q.waiters = nil
+	}
+
+	return batch, dropped
+}
+
+// sendWithRetries sends the batch and returns the entries that could not be sent
+// once the retries are exhausted.
+func (q *batchQueue) sendWithRetries(batch []batchEntry) ([]batchEntry, error) {
+	backoff := batchInitialBackoff
+
+	for attempt := 0; ; attempt++ {
+		err := q.send(batch)
+		if err == nil {
+			return nil, nil
+		}
+
+		var sendErr *batchSendError
+		if errors.As(err, &sendErr) {
+			batch = sendErr.unsent
+		}
+
+		if attempt >= q.maxRetries {
+			return batch, err
+		}
+
+		select {
+		case <-q.clock.After(backoff):
+		case <-q.done:
+			return batch, err
+		}
+
+		backoff *= 2
+	}
+}
@@ -0,0 +1,167 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type batchRecorder struct {
+	mutex   sync.Mutex
+	batches [][]string
+	errors  []error
+}
+
+func (r *batchRecorder) send(entries []batchEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if len(r.errors) > 0 {
+		err := r.errors[0]
+		r.errors = r.errors[1:]
+		return err
+	}
+
+	batch := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		batch = append(batch, entry.key+":"+string(entry.data))
+	}
+
+	r.batches = append(r.batches, batch)
+	return nil
+}
+
+func (r *batchRecorder) Batches() [][]string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([][]string(nil), r.batches...)
+}
+
+func TestBatchQueueMaxEntries(t *testing.T) {
+	recorder := &batchRecorder{}
+	queue := newBatchQueue(&Config{LogBatchMaxEntries: 2}, glock.NewMockClock(), recorder.send)
+	defer queue.Close()
+
+	queue.Add("a", []byte("1"))
+	queue.Add("b", []byte("2"))
+	queue.Add("a", []byte("3"))
+
+	requireEventually(t, func() bool { return len(recorder.Batches()) == 1 })
+	assert.Equal(t, [][]string{{"a:1", "b:2"}}, recorder.Batches())
+
+	require.Nil(t, queue.Sync())
+	assert.Equal(t, [][]string{{"a:1", "b:2"}, {"a:3"}}, recorder.Batches())
+}
+
+func TestBatchQueueMaxBytes(t *testing.T) {
+	recorder := &batchRecorder{}
+	queue := newBatchQueue(&Config{LogBatchMaxBytes: 6}, glock.NewMockClock(), recorder.send)
+	defer queue.Close()
+
+	queue.Add("a", []byte("123"))
+	queue.Add("a", []byte("45"))
+	queue.Add("a", []byte("6789"))
+
+	require.Nil(t, queue.Sync())
+	assert.Equal(t, [][]string{{"a:123", "a:45"}, {"a:6789"}}, recorder.Batches())
+}
+
+func TestBatchQueueFlushInterval(t *testing.T) {
+	recorder := &batchRecorder{}
+	clock := glock.NewMockClock()
+	queue := newBatchQueue(&Config{LogBatchFlushIntervalMillis: 500}, clock, recorder.send)
+	defer queue.Close()
+
+	queue.Add("a", []byte("1"))
+	assert.Empty(t, recorder.Batches())
+
+	clock.BlockingAdvance(time.Millisecond * 500)
+	requireEventually(t, func() bool { return len(recorder.Batches()) == 1 })
+	assert.Equal(t, [][]string{{"a:1"}}, recorder.Batches())
+}
+
+func TestBatchQueueRetries(t *testing.T) {
+	recorder := &batchRecorder{errors: []error{fmt.Errorf("a"), fmt.Errorf("b")}}
+	clock := glock.NewMockClock()
+	queue := newBatchQueue(&Config{LogBatchMaxEntries: 1, LogBatchMaxRetries: 2}, clock, recorder.send)
+	defer queue.Close()
+
+	queue.Add("a", []byte("1"))
+
+	// Wait for the flush interval and the first backoff
+	requireEventually(t, func() bool { return clock.BlockedOnAfter() == 2 })
+	clock.Advance(batchInitialBackoff)
+	requireEventually(t, func() bool { return clock.BlockedOnAfter() == 2 })
+	clock.Advance(batchInitialBackoff * 2)
+
+	requireEventually(t, func() bool { return len(recorder.Batches()) == 1 })
+	assert.Equal(t, [][]string{{"a:1"}}, recorder.Batches())
+}
+
+func TestBatchQueueDrops(t *testing.T) {
+	recorder := &batchRecorder{errors: []error{fmt.Errorf("a")}}
+	queue := newBatchQueue(&Config{LogOutputBufferSize: 2, LogBatchMaxRetries: -1}, glock.NewMockClock(), recorder.send)
+	defer queue.Close()
+
+	var reported []int
+	queue.onDrop = func(dropped int) { reported = append(reported, dropped) }
+
+	queue.Add("a", []byte("1"))
+	queue.Add("a", []byte("2"))
+	queue.Add("a", []byte("3"))
+
+	// The first batch fails without retries
+	require.Nil(t, queue.Sync())
+	assert.Empty(t, recorder.Batches())
+
+	queue.Add("a", []byte("4"))
+	require.Nil(t, queue.Sync())
+	assert.Equal(t, [][]string{{"a:4"}}, recorder.Batches())
+	assert.Equal(t, []int{3}, reported)
+}
+
+func TestBatchQueueRetriesUnsentEntries(t *testing.T) {
+	var (
+		mutex   sync.Mutex
+		batches [][]batchEntry
+	)
+
+	send := func(entries []batchEntry) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		batches = append(batches, entries)
+		if len(batches) == 1 {
+			return &batchSendError{err: fmt.Errorf("a"), unsent: entries[1:]}
+		}
+
+		return nil
+	}
+
+	clock := glock.NewMockClock()
+	queue := newBatchQueue(&Config{LogBatchMaxEntries: 2}, clock, send)
+	defer queue.Close()
+
+	queue.Add("a", []byte("1"))
+	queue.Add("b", []byte("2"))
+
+	requireEventually(t, func() bool { return clock.BlockedOnAfter() == 2 })
+	clock.Advance(batchInitialBackoff)
+	require.Nil(t, queue.Sync())
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	require.Len(t, batches, 2)
+	assert.Equal(t, []batchEntry{{key: "b", data: []byte("2")}}, batches[1])
+}
+
+func TestRetriesOrDefault(t *testing.T) {
+	assert.Equal(t, batchMaxRetries, retriesOrDefault(0, batchMaxRetries))
+	assert.Equal(t, 0, retriesOrDefault(-1, batchMaxRetries))
+	assert.Equal(t, 5, retriesOrDefault(5, batchMaxRetries))
+}
@@ -0,0 +1,130 @@
+package log
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// binaryFrameHeaderSize is the size of the big-endian length that precedes each
+// message written by the msgpack and cbor encodings.
+const binaryFrameHeaderSize = 4
+
+// binaryEncoders maps the name of each binary encoding to the function that appends
+// an encoded value to a buffer.
+var binaryEncoders = map[string]func(buf []byte, value interface{}) []byte{
+	"msgpack": appendMsgpack,
+	"cbor":    appendCBOR,
+}
+
+// binaryDecoders maps the name of each binary encoding to the function that decodes
+// a single value.
+var binaryDecoders = map[string]func(data []byte) (interface{}, error){
+	"msgpack": decodeMsgpack,
+	"cbor":    decodeCBOR,
+}
+
+// binaryLogger writes each message as a map prefixed by its length. Field values
+// keep their native types: integers, floats, and byte slices are not converted and
+// times are written with the timestamp type of the encoding.
+type binaryLogger struct {
+	stream io.Writer
+	encode func(buf []byte, value interface{}) []byte
+}
+
+func newBinaryLogger(encoding string, stream io.Writer) *binaryLogger {
+	return &binaryLogger{
+		stream: stream,
+		encode: binaryEncoders[encoding],
+	}
+}
+
+func (l *binaryLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	record := fields.clone()
+	record["message"] = msg
+	record["timestamp"] = timestamp
+	record["level"] = level.String()
+
+	buf := l.encode(make([]byte, binaryFrameHeaderSize, 256), record)
+	binary.BigEndian.PutUint32(buf, uint32(len(buf)-binaryFrameHeaderSize))
+
+	_, err := l.stream.Write(buf)
+	return err
+}
+
+func (l *binaryLogger) encodesNativeTimes() bool {
+	return true
+}
+
+// Record is a message read from the output of the msgpack or cbor encoding.
+type Record struct {
+	Timestamp time.Time
+	Level     LogLevel
+	Message   string
+	Fields    LogFields
+}
+
+// RecordDecoder reads the length-prefixed messages written by the msgpack or cbor
+// encoding.
+type RecordDecoder struct {
+	r      *bufio.Reader
+	decode func(data []byte) (interface{}, error)
+}
+
+// NewRecordDecoder creates a decoder that reads messages in the given encoding,
+// which must be either msgpack or cbor.
+func NewRecordDecoder(r io.Reader, encoding string) (*RecordDecoder, error) {
+	decode, ok := binaryDecoders[encoding]
+	if !ok {
+		return nil, ErrIllegalEncoding
+	}
+
+	return &RecordDecoder{r: bufio.NewReader(r), decode: decode}, nil
+}
+
+// Decode reads the next message from the stream. It returns io.EOF once the stream
+// ends between messages.
+func (d *RecordDecoder) Decode() (Record, error) {
+	var header [binaryFrameHeaderSize]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return Record{}, fmt.Errorf("truncated message header")
+		}
+
+		return Record{}, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(header[:]))
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return Record{}, fmt.Errorf("truncated message: %w", err)
+	}
+
+	value, err := d.decode(data)
+	if err != nil {
+		return Record{}, err
+	}
+
+	fields, ok := value.(map[string]interface{})
+	if !ok {
+		return Record{}, fmt.Errorf("message is not a map")
+	}
+
+	record := Record{Fields: fields}
+	if timestamp, ok := fields["timestamp"].(time.Time); ok {
+		record.Timestamp = timestamp
+	}
+	if level, ok := fields["level"].(string); ok {
+		record.Level = parseLogLevel(level)
+	}
+	if message, ok := fields["message"].(string); ok {
+		record.Message = message
+	}
+
+	delete(fields, "timestamp")
+	delete(fields, "level")
+	delete(fields, "message")
+
+	return record, nil
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryLoggerLog(t *testing.T) {
+	for _, encoding := range []string{"msgpack", "cbor"} {
+		t.Run(encoding, func(t *testing.T) {
+			buffer := bytes.NewBuffer(nil)
+			logger := newBinaryLogger(encoding, buffer)
+			timestamp := time.Unix(1503939881, 0)
+
+			require.Nil(t, logger.Log(timestamp, LevelWarning, LogFields{"attr1": 4321, "attr2": []byte{1, 2}}, "test 1234"))
+			require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"ratio": 0.5}, "test 5678"))
+
+			decoder, err := NewRecordDecoder(buffer, encoding)
+			require.Nil(t, err)
+
+			record, err := decoder.Decode()
+			require.Nil(t, err)
+			assert.True(t, timestamp.Equal(record.Timestamp))
+			assert.Equal(t, LevelWarning, record.Level)
+			assert.Equal(t, "test 1234", record.Message)
+			assert.Equal(t, LogFields{"attr1": int64(4321), "attr2": []byte{1, 2}}, record.Fields)
+
+			record, err = decoder.Decode()
+			require.Nil(t, err)
+			assert.Equal(t, LevelInfo, record.Level)
+			assert.Equal(t, LogFields{"ratio": 0.5}, record.Fields)
+
+			_, err = decoder.Decode()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestBinaryLoggerPreservesTimeFields(t *testing.T) {
+	for _, encoding := range []string{"msgpack", "cbor"} {
+		t.Run(encoding, func(t *testing.T) {
+			buffer := bytes.NewBuffer(nil)
+			clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+			started := time.Unix(1503930000, 0)
+
+			logger, err := InitLogger(&Config{LogLevel: "info", LogEncoding: encoding}, WithOutput(buffer), WithClock(clock))
+			require.Nil(t, err)
+			logger.InfoWithFields(LogFields{"started": started}, "test 1234")
+
+			decoder, err := NewRecordDecoder(buffer, encoding)
+			require.Nil(t, err)
+
+			record, err := decoder.Decode()
+			require.Nil(t, err)
+			assert.True(t, clock.Now().Equal(record.Timestamp))
+			assert.True(t, started.Equal(record.Fields["started"].(time.Time)))
+			assert.Equal(t, int64(1), record.Fields[fieldSequenceNumber])
+		})
+	}
+}
+
+func TestRecordDecoderTruncated(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	require.Nil(t, newBinaryLogger("msgpack", buffer).Log(time.Now(), LevelInfo, nil, "test 1234"))
+
+	decoder, err := NewRecordDecoder(bytes.NewReader(buffer.Bytes()[:buffer.Len()-2]), "msgpack")
+	require.Nil(t, err)
+
+	_, err = decoder.Decode()
+	assert.NotNil(t, err)
+	assert.NotEqual(t, io.EOF, err)
+}
+
+func TestNewRecordDecoderIllegalEncoding(t *testing.T) {
+	_, err := NewRecordDecoder(bytes.NewReader(nil), "json")
+	assert.Equal(t, ErrIllegalEncoding, err)
+}
@@ -2,7 +2,10 @@ package log
 
 import (
 	"fmt"
+	"path"
+	"reflect"
 	"runtime"
+	"strconv"
 	"strings"
 )
 
@@ -29,6 +32,53 @@ func getCaller(depth int) string {
 	}
 }
 
+// getExternalCaller returns the location of the first frame on the stack outside
+// of the standard library and the Writer adapter.
+func getExternalCaller() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	for {
+		frame, more := frames.Next()
+		if !isAdapterFrame(frame.Function) && !isStdlibFrame(frame.File) && frame.File != "<autogenerated>" {
+			return fmt.Sprintf("%s:%d", trimPath(frame.File), frame.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+// stdlibSourceDir is the directory containing the standard library sources as
+// recorded in the binary, or an empty string if it cannot be determined, as is the
+// case for binaries built with -trimpath.
+var stdlibSourceDir = func() string {
+	file, _ := runtime.FuncForPC(reflect.ValueOf(fmt.Fprintf).Pointer()).FileLine(0)
+	if dir := path.Dir(path.Dir(file)); path.IsAbs(dir) {
+		return dir + "/"
+	}
+
+	return ""
+}()
+
+func isStdlibFrame(file string) bool {
+	return stdlibSourceDir != "" && strings.HasPrefix(file, stdlibSourceDir)
+}
+
+// isAdapterFrame returns true for frames of the Writer adapter and of the standard
+// library packages that commonly write to it. The packages are listed for builds in
+// which stdlibSourceDir cannot be determined.
+func isAdapterFrame(function string) bool {
+	for _, prefix := range []string{"log.", "log/internal.", "fmt.", "io.", "bufio.", "github.com/go-nacelle/log/v2.(*Writer)."} {
+		if strings.HasPrefix(function, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func trimPath(path string) string {
 	// For details, see http://goo.gl/FL2U8s.
 
@@ -41,3 +91,23 @@ func trimPath(path string) string {
 
 	return path
 }
+
+// splitCaller splits a caller field value of the form file:line into its parts.
+func splitCaller(value interface{}) (string, int, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return "", 0, false
+	}
+
+	idx := strings.LastIndexByte(s, ':')
+	if idx < 0 {
+		return "", 0, false
+	}
+
+	line, err := strconv.Atoi(s[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return s[:idx], line, true
+}
@@ -35,7 +35,7 @@ var (
 	testFields3 = LogFields{"C": 3}
 )
 
-func testBasic(t *testing.T, init func(*Config) (Logger, error)) {
+func testBasic(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
 	stderr := captureStderr(func() {
 		logger, err := init(&Config{LogLevel: "info", LogEncoding: "json"})
 		require.Nil(t, err)
@@ -66,13 +66,13 @@ func testBasic(t *testing.T, init func(*Config) (Logger, error)) {
 	assert.Equal(t, fmt.Sprintf("log/caller_test.go:%d", start+2), data3["caller"])
 }
 
-func testReplay(t *testing.T, init func(*Config) (Logger, error)) {
+func testReplay(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
 	stderr := captureStderr(func() {
 		logger, err := init(&Config{LogLevel: "info", LogEncoding: "json"})
 		require.Nil(t, err)
 
 		// Non-replayed messages are below log level - not emitted
-		replayLogger := NewReplayLogger(logger, LevelDebug, LevelInfo)
+		replayLogger := NewReplayLogger(logger, LevelDebug, WithReplayLevels(LevelInfo))
 		replayLogger.Debug("X")
 		replayLogger.InfoWithFields(LogFields{"empty": false}, "Y")
 		replayLogger.Debug("Z")
@@ -100,7 +100,7 @@ func testReplay(t *testing.T, init func(*Config) (Logger, error)) {
 	assert.Equal(t, fmt.Sprintf("log/caller_test.go:%d", start+2), data3["caller"])
 }
 
-func testRollup(t *testing.T, init func(*Config) (Logger, error)) {
+func testRollup(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
 	stderr := captureStderr(func() {
 		logger, err := init(&Config{LogLevel: "info", LogEncoding: "json"})
 		require.Nil(t, err)
@@ -133,7 +133,7 @@ func testRollup(t *testing.T, init func(*Config) (Logger, error)) {
 	assert.Equal(t, fmt.Sprintf("log/caller_test.go:%d", start), data2["caller"])
 }
 
-func testIndirect(t *testing.T, init func(*Config) (Logger, error)) {
+func testIndirect(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
 	stderr := captureStderr(func() {
 		logger, err := init(&Config{LogLevel: "info", LogEncoding: "json"})
 		require.Nil(t, err)
@@ -170,9 +170,9 @@ func testIndirect(t *testing.T, init func(*Config) (Logger, error)) {
 	assert.Equal(t, fmt.Sprintf("log/caller_test.go:%d", start+2), data3["caller"])
 }
 
-func testFields(t *testing.T, init func(*Config) (Logger, error)) {
-	testBasic(t, func(config *Config) (Logger, error) {
-		logger, err := init(config)
+func testFields(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
+	testBasic(t, func(config *Config, configs ...LoggerConfigFunc) (Logger, error) {
+		logger, err := init(config, configs...)
 		if err != nil {
 			return nil, err
 		}
@@ -181,20 +181,20 @@ func testFields(t *testing.T, init func(*Config) (Logger, error)) {
 	})
 }
 
-func testReplayLogger(t *testing.T, init func(*Config) (Logger, error)) {
-	testBasic(t, func(config *Config) (Logger, error) {
-		logger, err := init(config)
+func testReplayLogger(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
+	testBasic(t, func(config *Config, configs ...LoggerConfigFunc) (Logger, error) {
+		logger, err := init(config, configs...)
 		if err != nil {
 			return nil, err
 		}
 
-		return NewReplayLogger(NewReplayLogger(NewReplayLogger(logger))), nil
+		return NewReplayLogger(NewReplayLogger(NewReplayLogger(logger, LevelDebug), LevelDebug), LevelDebug), nil
 	})
 }
 
-func testRollupLogger(t *testing.T, init func(*Config) (Logger, error)) {
-	testBasic(t, func(config *Config) (Logger, error) {
-		logger, err := init(config)
+func testRollupLogger(t *testing.T, init func(*Config, ...LoggerConfigFunc) (Logger, error)) {
+	testBasic(t, func(config *Config, configs ...LoggerConfigFunc) (Logger, error) {
+		logger, err := init(config, configs...)
 		if err != nil {
 			return nil, err
 		}
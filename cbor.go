@@ -0,0 +1,364 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	cborMajorUint   = 0
+	cborMajorNegInt = 1
+	cborMajorBytes  = 2
+	cborMajorText   = 3
+	cborMajorArray  = 4
+	cborMajorMap    = 5
+	cborMajorTag    = 6
+	cborMajorSimple = 7
+)
+
+const (
+	// cborTagDateTime is the standard tag for an RFC 3339 date/time string.
+	cborTagDateTime = 0
+
+	// cborTagEpoch is the standard tag for an epoch-based date/time.
+	cborTagEpoch = 1
+)
+
+// cborTag is a tagged value with a tag number for which no native decoding exists.
+type cborTag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// appendCBOR appends the CBOR encoding of the given value to buf. Values without a
+// native CBOR representation are encoded as they would be by encoding/json. Map
+// keys are written in sorted order.
+func appendCBOR(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xf6)
+	case bool:
+		if v {
+			return append(buf, 0xf5)
+		}
+		return append(buf, 0xf4)
+	case int:
+		return appendCBORInt(buf, int64(v))
+	case int8:
+		return appendCBORInt(buf, int64(v))
+	case int16:
+		return appendCBORInt(buf, int64(v))
+	case int32:
+		return appendCBORInt(buf, int64(v))
+	case int64:
+		return appendCBORInt(buf, v)
+	case uint:
+		return appendCBORHead(buf, cborMajorUint, uint64(v))
+	case uint8:
+		return appendCBORHead(buf, cborMajorUint, uint64(v))
+	case uint16:
+		return appendCBORHead(buf, cborMajorUint, uint64(v))
+	case uint32:
+		return appendCBORHead(buf, cborMajorUint, uint64(v))
+	case uint64:
+		return appendCBORHead(buf, cborMajorUint, v)
+	case float32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xfa), math.Float32bits(v))
+	case float64:
+		return binary.BigEndian.AppendUint64(append(buf, 0xfb), math.Float64bits(v))
+	case string:
+		return append(appendCBORHead(buf, cborMajorText, uint64(len(v))), v...)
+	case []byte:
+		return append(appendCBORHead(buf, cborMajorBytes, uint64(len(v))), v...)
+	case time.Time:
+		return appendCBORTime(buf, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendCBORInt(buf, i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return appendCBOR(buf, f)
+		}
+		return appendCBOR(buf, string(v))
+	case LogFields:
+		return appendCBORMap(buf, v)
+	case map[string]interface{}:
+		return appendCBORMap(buf, v)
+	case []interface{}:
+		buf = appendCBORHead(buf, cborMajorArray, uint64(len(v)))
+		for _, elem := range v {
+			buf = appendCBOR(buf, elem)
+		}
+		return buf
+	case cborTag:
+		return appendCBOR(appendCBORHead(buf, cborMajorTag, v.Number), v.Content)
+	case error:
+		return appendCBOR(buf, v.Error())
+	}
+
+	return appendCBOR(buf, nativeValue(value))
+}
+
+// appendCBORHead appends the initial byte of a data item of the given major type
+// followed by the argument in its shortest form.
+func appendCBORHead(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major<<5|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major<<5|24, byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, major<<5|25), uint16(n))
+	case n <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, major<<5|26), uint32(n))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, major<<5|27), n)
+}
+
+func appendCBORInt(buf []byte, v int64) []byte {
+	if v < 0 {
+		return appendCBORHead(buf, cborMajorNegInt, uint64(^v))
+	}
+
+	return appendCBORHead(buf, cborMajorUint, uint64(v))
+}
+
+func appendCBORMap(buf []byte, m map[string]interface{}) []byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf = appendCBORHead(buf, cborMajorMap, uint64(len(m)))
+	for _, key := range keys {
+		buf = appendCBOR(buf, key)
+		buf = appendCBOR(buf, m[key])
+	}
+
+	return buf
+}
+
+// appendCBORTime encodes a time as an epoch-based date/time. Whole seconds are
+// written as an integer, and all other times as a float with microsecond precision.
+func appendCBORTime(buf []byte, t time.Time) []byte {
+	buf = appendCBORHead(buf, cborMajorTag, cborTagEpoch)
+
+	if t.Nanosecond() == 0 {
+		return appendCBORInt(buf, t.Unix())
+	}
+
+	return appendCBOR(buf, float64(t.Unix())+float64(t.Nanosecond())/1e9)
+}
+
+// cborDecoder reads CBOR values from a stream. Maps are decoded as
+// map[string]interface{}, arrays as []interface{}, integers as int64 (or uint64 if
+// they overflow an int64), date/time tags as time.Time, and other tags as cborTag.
+// Indefinite-length items are not supported.
+type cborDecoder struct {
+	r *bufio.Reader
+}
+
+func newCBORDecoder(r io.Reader) *cborDecoder {
+	return &cborDecoder{r: bufio.NewReader(r)}
+}
+
+// decodeCBOR decodes a single value from the given data.
+func decodeCBOR(data []byte) (interface{}, error) {
+	return newCBORDecoder(bytes.NewReader(data)).Decode()
+}
+
+// Decode reads the next value from the stream.
+func (d *cborDecoder) Decode() (interface{}, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	major, info := b>>5, b&0x1f
+
+	if major == cborMajorSimple {
+		return d.decodeSimple(info)
+	}
+
+	n, err := d.readArgument(info)
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborMajorUint:
+		if n > math.MaxInt64 {
+			return n, nil
+		}
+		return int64(n), nil
+	case cborMajorNegInt:
+		if n > math.MaxInt64 {
+			return nil, fmt.Errorf("cbor negative integer overflows int64")
+		}
+		return ^int64(n), nil
+	case cborMajorBytes:
+		return d.read(n)
+	case cborMajorText:
+		data, err := d.read(n)
+		return string(data), err
+	case cborMajorArray:
+		return d.decodeArray(n)
+	case cborMajorMap:
+		return d.decodeMap(n)
+	}
+
+	return d.decodeTag(n)
+}
+
+// readArgument reads the argument that follows the initial byte of a data item.
+func (d *cborDecoder) readArgument(info byte) (uint64, error) {
+	switch {
+	case info < 24:
+		return uint64(info), nil
+	case info <= 27:
+		return d.readUint(1 << (info - 24))
+	}
+
+	return 0, fmt.Errorf("unsupported cbor additional information %d", info)
+}
+
+func (d *cborDecoder) readUint(size int) (uint64, error) {
+	data, err := d.read(uint64(size))
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+
+	return v, nil
+}
+
+func (d *cborDecoder) read(n uint64) ([]byte, error) {
+	if n > math.MaxInt32 {
+		return nil, fmt.Errorf("cbor item length %d is too large", n)
+	}
+
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (d *cborDecoder) decodeSimple(info byte) (interface{}, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		v, err := d.readUint(2)
+		return halfToFloat64(uint16(v)), err
+	case 26:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 27:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	}
+
+	return nil, fmt.Errorf("unsupported cbor simple value %d", info)
+}
+
+func (d *cborDecoder) decodeArray(n uint64) (interface{}, error) {
+	values := make([]interface{}, 0, min(n, 1024))
+	for i := uint64(0); i < n; i++ {
+		value, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func (d *cborDecoder) decodeMap(n uint64) (interface{}, error) {
+	values := make(map[string]interface{}, min(n, 1024))
+	for i := uint64(0); i < n; i++ {
+		key, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := key.(string); ok {
+			values[s] = value
+		} else {
+			values[fmt.Sprintf("%v", key)] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (d *cborDecoder) decodeTag(number uint64) (interface{}, error) {
+	content, err := d.Decode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch number {
+	case cborTagDateTime:
+		if s, ok := content.(string); ok {
+			return time.Parse(time.RFC3339Nano, s)
+		}
+	case cborTagEpoch:
+		switch v := content.(type) {
+		case int64:
+			return time.Unix(v, 0), nil
+		case float64:
+			sec, frac := math.Modf(v)
+			return time.Unix(int64(sec), int64(math.Round(frac*1e6))*1e3), nil
+		}
+	}
+
+	return cborTag{Number: number, Content: content}, nil
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float to a float64.
+func halfToFloat64(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+
+	switch exp {
+	case 0:
+		return sign * math.Ldexp(mant, -24)
+	case 0x1f:
+		if mant == 0 {
+			return math.Inf(int(sign))
+		}
+		return math.NaN()
+	}
+
+	return sign * math.Ldexp(mant+1024, exp-25)
+}
@@ -0,0 +1,91 @@
+package log
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCBOREncoding(t *testing.T) {
+	assert.Equal(t, []byte{0xf6}, appendCBOR(nil, nil))
+	assert.Equal(t, []byte{0xf5}, appendCBOR(nil, true))
+	assert.Equal(t, []byte{0x17}, appendCBOR(nil, 23))
+	assert.Equal(t, []byte{0x18, 0x18}, appendCBOR(nil, 24))
+	assert.Equal(t, []byte{0x19, 0x01, 0x00}, appendCBOR(nil, 256))
+	assert.Equal(t, []byte{0x20}, appendCBOR(nil, -1))
+	assert.Equal(t, []byte{0x38, 0x63}, appendCBOR(nil, -100))
+	assert.Equal(t, []byte{0x63, 'f', 'o', 'o'}, appendCBOR(nil, "foo"))
+	assert.Equal(t, []byte{0x42, 0x01, 0x02}, appendCBOR(nil, []byte{1, 2}))
+	assert.Equal(t, []byte{0xa2, 0x61, 'a', 0x01, 0x61, 'b', 0x02}, appendCBOR(nil, LogFields{"b": 2, "a": 1}))
+	assert.Equal(t, []byte{0x82, 0x01, 0x61, 'x'}, appendCBOR(nil, []interface{}{1, "x"}))
+	assert.Equal(t, []byte{0xc1, 0x1a, 0x59, 0xa4, 0x4d, 0x29}, appendCBOR(nil, time.Unix(1503939881, 0)))
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	testCases := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{false, false},
+		{42, int64(42)},
+		{-1000000, int64(-1000000)},
+		{int64(math.MinInt64), int64(math.MinInt64)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{float32(1.5), float64(1.5)},
+		{3.25, 3.25},
+		{"", ""},
+		{string(make([]byte, 300)), string(make([]byte, 300))},
+		{[]byte("raw"), []byte("raw")},
+		{time.Unix(1503939881, 0), time.Unix(1503939881, 0)},
+		{time.Unix(1503939881, 123456000), time.Unix(1503939881, 123456000)},
+		{errors.New("oops"), "oops"},
+		{msgpackTestLevel(3), int64(3)},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": int64(1)}},
+		{msgpackTestStruct{Name: "x", Count: 2}, map[string]interface{}{"name": "x", "count": int64(2)}},
+		{cborTag{Number: 32, Content: "http://example.com"}, cborTag{Number: 32, Content: "http://example.com"}},
+		{
+			LogFields{"nested": LogFields{"list": []interface{}{1, "two", nil}}},
+			map[string]interface{}{"nested": map[string]interface{}{"list": []interface{}{int64(1), "two", nil}}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		value, err := decodeCBOR(appendCBOR(nil, testCase.value))
+		require.Nil(t, err)
+
+		if expected, ok := testCase.expected.(time.Time); ok {
+			assert.True(t, expected.Equal(value.(time.Time)))
+			continue
+		}
+
+		assert.Equal(t, testCase.expected, value)
+	}
+}
+
+func TestCBORDecodeStandardForms(t *testing.T) {
+	// half-precision float
+	value, err := decodeCBOR([]byte{0xf9, 0x3e, 0x00})
+	require.Nil(t, err)
+	assert.Equal(t, 1.5, value)
+
+	// RFC 3339 date/time string
+	value, err = decodeCBOR(append([]byte{0xc0, 0x74}, "2017-08-28T17:04:41Z"...))
+	require.Nil(t, err)
+	assert.True(t, time.Unix(1503939881, 0).Equal(value.(time.Time)))
+
+	// indefinite-length array
+	_, err = decodeCBOR([]byte{0x9f, 0x01, 0xff})
+	assert.NotNil(t, err)
+}
+
+func TestCBORDecodeTruncated(t *testing.T) {
+	data := appendCBOR(nil, "truncated")
+	_, err := decodeCBOR(data[:4])
+	assert.NotNil(t, err)
+}
@@ -2,24 +2,204 @@ package log
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 )
 
+// Config holds the options read by InitLogger. Options of an output or encoding
+// other than the selected one are ignored. The default of each option is listed in
+// its default tag. Options without a default tag, and options of outputs that
+// apply a default when the option is empty, are described below.
 type Config struct {
-	LogLevel                  string            `env:"log_level" file:"log_level" default:"info"`
-	LogEncoding               string            `env:"log_encoding" file:"log_encoding" default:"console"`
-	LogColorize               bool              `env:"log_colorize" file:"log_colorize" default:"true"`
-	LogJSONFieldNames         map[string]string `env:"log_json_field_names" file:"log_json_field_names"`
-	LogInitialFields          LogFields         `env:"log_fields" file:"log_fields"`
-	LogShortTime              bool              `env:"log_short_time" file:"log_short_time" default:"false"`
-	LogDisplayFields          bool              `env:"log_display_fields" file:"log_display_fields" default:"true"`
-	LogDisplayMultilineFields bool              `env:"log_display_multiline_fields" file:"log_display_multiline_fields" default:"false"`
-	LogFieldBlacklist         []string          `env:"log_field_blacklist" file:"log_field_blacklist"`
+	// LogLevel is the maximum level of written messages: debug, info, warning,
+	// error, panic, or fatal.
+	LogLevel string `env:"log_level" file:"log_level" default:"info"`
+
+	// LogEncoding is one of console, json, logfmt, msgpack, cbor, syslog, journald,
+	// gelf, fluent, otlp, or http.
+	LogEncoding string `env:"log_encoding" file:"log_encoding" default:"console"`
+
+	// LogInitialFields are attached to every message.
+	LogInitialFields LogFields `env:"log_fields" file:"log_fields"`
+
+	// LogUseOriginalTimestamp logs replayed and rolled up messages at the time
+	// they were originally logged.
+	LogUseOriginalTimestamp bool `env:"log_use_original_timestamp" file:"log_use_original_timestamp" default:"false"`
+
+	//
+	// Console, JSON, and logfmt encodings
+
+	// LogColorize colors console messages by level.
+	LogColorize bool `env:"log_colorize" file:"log_colorize" default:"true"`
+
+	// LogShortTime writes only the time of day in console messages.
+	LogShortTime bool `env:"log_short_time" file:"log_short_time" default:"false"`
+
+	// LogDisplayFields writes fields in console messages.
+	LogDisplayFields bool `env:"log_display_fields" file:"log_display_fields" default:"true"`
+
+	// LogDisplayMultilineFields writes each field of a console message on its own line.
+	LogDisplayMultilineFields bool `env:"log_display_multiline_fields" file:"log_display_multiline_fields" default:"false"`
+
+	// LogFieldBlacklist lists the fields omitted from console messages.
+	LogFieldBlacklist []string `env:"log_field_blacklist" file:"log_field_blacklist"`
+
+	// LogJSONFieldNames renames the message, timestamp, and level keys of JSON messages.
+	LogJSONFieldNames map[string]string `env:"log_json_field_names" file:"log_json_field_names"`
+
+	// LogJSONProfile selects the JSON layout expected by gcp, ecs, or datadog. By
+	// default, the layout is not changed.
+	LogJSONProfile string `env:"log_json_profile" file:"log_json_profile"`
+
+	// LogFieldPriority lists the fields written first, in order.
+	LogFieldPriority []string `env:"log_field_priority" file:"log_field_priority"`
+
+	// LogFieldOrder writes the remaining fields sorted by key or in insertion order.
+	LogFieldOrder string `env:"log_field_order" file:"log_field_order" default:"sorted"`
+
+	// LogTimestampFormat is rfc3339, rfc3339nano, unix, unixmilli, unixnano, or a
+	// time layout. By default, each encoding uses its own format.
+	LogTimestampFormat string `env:"log_timestamp_format" file:"log_timestamp_format"`
+
+	// LogTimezone is the timezone of timestamps: UTC, local, or an IANA name.
+	LogTimezone string `env:"log_timezone" file:"log_timezone" default:"UTC"`
+
+	// LogFormatTimeFields formats time values in fields like timestamps.
+	LogFormatTimeFields bool `env:"log_format_time_fields" file:"log_format_time_fields" default:"false"`
+
+	//
+	// Network output of the console, JSON, logfmt, msgpack, and cbor encodings
+
+	// LogOutputAddress is a tcp://, unix://, or tls:// address. By default,
+	// messages are written to stderr.
+	LogOutputAddress string `env:"log_output_address" file:"log_output_address"`
+
+	// LogOutputBufferSize is the number of messages buffered by network outputs and
+	// by the fluent, otlp, and http encodings.
+	LogOutputBufferSize int `env:"log_output_buffer_size" file:"log_output_buffer_size" default:"1024"`
+
+	// LogOutputTLSCAFile is a PEM file of CA certificates trusted by tls:// outputs.
+	// By default, the system roots are trusted.
+	LogOutputTLSCAFile string `env:"log_output_tls_ca_file" file:"log_output_tls_ca_file"`
+
+	// LogOutputTLSInsecureSkipVerify disables certificate verification of tls:// outputs.
+	LogOutputTLSInsecureSkipVerify bool `env:"log_output_tls_insecure_skip_verify" file:"log_output_tls_insecure_skip_verify" default:"false"`
+
+	//
+	// Syslog encoding
+
+	// LogSyslogFormat is rfc5424 or rfc3164.
+	LogSyslogFormat string `env:"log_syslog_format" file:"log_syslog_format" default:"rfc5424"`
+
+	// LogSyslogNetwork is udp or tcp. By default, messages are written to the
+	// local syslog socket.
+	LogSyslogNetwork string `env:"log_syslog_network" file:"log_syslog_network"`
+
+	// LogSyslogAddress is the remote address, or the path of the local socket,
+	// which defaults to /dev/log.
+	LogSyslogAddress string `env:"log_syslog_address" file:"log_syslog_address"`
+
+	// LogSyslogFacility is the facility name, such as user or local0.
+	LogSyslogFacility string `env:"log_syslog_facility" file:"log_syslog_facility" default:"user"`
+
+	// LogSyslogAppName defaults to the name of the executable.
+	LogSyslogAppName string `env:"log_syslog_app_name" file:"log_syslog_app_name"`
+
+	// LogSyslogProcID defaults to the process ID.
+	LogSyslogProcID string `env:"log_syslog_proc_id" file:"log_syslog_proc_id"`
+
+	// LogSyslogStructuredDataID defaults to DefaultSyslogStructuredDataID.
+	LogSyslogStructuredDataID string `env:"log_syslog_structured_data_id" file:"log_syslog_structured_data_id"`
+
+	//
+	// Journald encoding
+
+	// LogJournaldSocket defaults to DefaultJournaldSocket.
+	LogJournaldSocket string `env:"log_journald_socket" file:"log_journald_socket"`
+
+	//
+	// GELF encoding
+
+	// LogGELFAddress is a required udp:// or tcp:// address.
+	LogGELFAddress string `env:"log_gelf_address" file:"log_gelf_address"`
+
+	// LogGELFChunkSize is the maximum size of a UDP datagram. Zero selects
+	// DefaultGELFChunkSize.
+	LogGELFChunkSize int `env:"log_gelf_chunk_size" file:"log_gelf_chunk_size" default:"1420"`
+
+	//
+	// Fluent encoding
+
+	// LogFluentAddress is a tcp://, unix://, or tls:// address. It defaults to
+	// DefaultFluentAddress.
+	LogFluentAddress string `env:"log_fluent_address" file:"log_fluent_address"`
+
+	// LogFluentTag is the tag of messages without a FieldFluentTag field.
+	LogFluentTag string `env:"log_fluent_tag" file:"log_fluent_tag" default:"app"`
+
+	// LogFluentRequireAck waits for the server to acknowledge each chunk.
+	LogFluentRequireAck bool `env:"log_fluent_require_ack" file:"log_fluent_require_ack" default:"false"`
+
+	//
+	// OTLP encoding
+
+	// LogOTLPEndpoint defaults to DefaultOTLPEndpoint.
+	LogOTLPEndpoint string `env:"log_otlp_endpoint" file:"log_otlp_endpoint"`
+
+	// LogOTLPProtocol is http/protobuf or http/json. It defaults to http/protobuf.
+	LogOTLPProtocol string `env:"log_otlp_protocol" file:"log_otlp_protocol"`
+
+	// LogOTLPHeaders are sent with each export request.
+	LogOTLPHeaders map[string]string `env:"log_otlp_headers" file:"log_otlp_headers"`
+
+	//
+	// HTTP encoding
+
+	// LogHTTPEndpoint is the required URL to which batches are pushed.
+	LogHTTPEndpoint string `env:"log_http_endpoint" file:"log_http_endpoint"`
+
+	// LogHTTPFormat is json, loki, or elasticsearch.
+	LogHTTPFormat string `env:"log_http_format" file:"log_http_format" default:"json"`
+
+	// LogHTTPHeaders are sent with each request.
+	LogHTTPHeaders map[string]string `env:"log_http_headers" file:"log_http_headers"`
+
+	// LogHTTPGzip compresses request bodies.
+	LogHTTPGzip bool `env:"log_http_gzip" file:"log_http_gzip" default:"false"`
+
+	// LogLokiLabels lists the fields sent as Loki stream labels.
+	LogLokiLabels []string `env:"log_loki_labels" file:"log_loki_labels"`
+
+	// LogElasticsearchIndex is the index of the _bulk API.
+	LogElasticsearchIndex string `env:"log_elasticsearch_index" file:"log_elasticsearch_index" default:"logs"`
+
+	//
+	// Batching of the fluent, otlp, and http encodings
+
+	// LogBatchMaxEntries is the maximum number of messages in a batch.
+	LogBatchMaxEntries int `env:"log_batch_max_entries" file:"log_batch_max_entries" default:"512"`
+
+	// LogBatchMaxBytes is the maximum encoded size of a batch.
+	LogBatchMaxBytes int `env:"log_batch_max_bytes" file:"log_batch_max_bytes" default:"1048576"`
+
+	// LogBatchFlushIntervalMillis is the maximum time a message waits to be sent.
+	LogBatchFlushIntervalMillis int `env:"log_batch_flush_interval_millis" file:"log_batch_flush_interval_millis" default:"1000"`
+
+	// LogBatchMaxRetries is the number of times a failed batch is retried. Zero
+	// selects the default and a negative value disables retries.
+	LogBatchMaxRetries int `env:"log_batch_max_retries" file:"log_batch_max_retries" default:"3"`
 }
 
 var (
-	ErrIllegalLevel    = fmt.Errorf("illegal log level")
-	ErrIllegalEncoding = fmt.Errorf("illegal log encoding")
+	ErrIllegalLevel          = fmt.Errorf("illegal log level")
+	ErrIllegalEncoding       = fmt.Errorf("illegal log encoding")
+	ErrIllegalSyslogFormat   = fmt.Errorf("illegal syslog format")
+	ErrIllegalSyslogFacility = fmt.Errorf("illegal syslog facility")
+	ErrIllegalOutputAddress  = fmt.Errorf("illegal log output address")
+	ErrIllegalJSONProfile    = fmt.Errorf("illegal JSON profile")
+	ErrIllegalTimezone       = fmt.Errorf("illegal log timezone")
+	ErrIllegalFieldOrder     = fmt.Errorf("illegal log field order")
+	ErrIllegalGELFChunkSize  = fmt.Errorf("illegal GELF chunk size")
 )
 
 func (c *Config) PostLoad() error {
@@ -39,10 +219,74 @@ func (c *Config) PostLoad() error {
 		}
 	}
 
+	if c.LogJSONProfile != "" {
+		c.LogJSONProfile = strings.ToLower(c.LogJSONProfile)
+
+		if _, ok := jsonProfiles[c.LogJSONProfile]; !ok {
+			return ErrIllegalJSONProfile
+		}
+	}
+
+	c.LogFieldOrder = strings.ToLower(c.LogFieldOrder)
+
+	if c.LogFieldOrder != "" && c.LogFieldOrder != "sorted" && c.LogFieldOrder != "insertion" {
+		return ErrIllegalFieldOrder
+	}
+
+	if _, err := loadTimezone(c.LogTimezone); err != nil {
+		return err
+	}
+
 	for i, name := range c.LogFieldBlacklist {
 		c.LogFieldBlacklist[i] = strings.ToLower(name)
 	}
 
+	if c.LogEncoding == "syslog" {
+		c.LogSyslogFormat = strings.ToLower(c.LogSyslogFormat)
+		c.LogSyslogFacility = strings.ToLower(c.LogSyslogFacility)
+
+		if c.LogSyslogFormat != "rfc5424" && c.LogSyslogFormat != "rfc3164" {
+			return ErrIllegalSyslogFormat
+		}
+
+		if _, ok := syslogFacilities[c.LogSyslogFacility]; !ok {
+			return ErrIllegalSyslogFacility
+		}
+	}
+
+	if c.LogEncoding == "gelf" {
+		if u, err := url.Parse(c.LogGELFAddress); err != nil || (u.Scheme != "udp" && u.Scheme != "tcp") {
+			return ErrIllegalOutputAddress
+		}
+
+		// Zero selects DefaultGELFChunkSize
+		if c.LogGELFChunkSize != 0 && c.LogGELFChunkSize <= gelfChunkHeaderSize {
+			return ErrIllegalGELFChunkSize
+		}
+	}
+
+	if c.LogEncoding == "fluent" {
+		if _, err := newStreamDialer(fluentAddress(c), c); err != nil {
+			return err
+		}
+	}
+
+	if c.LogEncoding == "otlp" && c.LogOTLPProtocol != "" && c.LogOTLPProtocol != "http/protobuf" && c.LogOTLPProtocol != "http/json" {
+		return ErrIllegalOTLPProtocol
+	}
+
+	if c.LogEncoding == "http" {
+		if _, err := newHTTPPayloadBuilder(c); err != nil {
+			return err
+		}
+	}
+
+	if c.LogOutputAddress != "" {
+		if _, err := newStreamDialer(c.LogOutputAddress, c); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -56,8 +300,28 @@ func isLegalLevel(level string) bool {
 	return false
 }
 
+var encodings = []string{
+	"console",
+	"json",
+	"logfmt",
+	"msgpack",
+	"cbor",
+	"syslog",
+	"journald",
+	"gelf",
+	"fluent",
+	"otlp",
+	"http",
+}
+
 func isLegalEncoding(encoding string) bool {
-	return encoding == "console" || encoding == "json"
+	for _, whitelisted := range encodings {
+		if encoding == whitelisted {
+			return true
+		}
+	}
+
+	return false
 }
 
 func isLegalJSONFieldName(name string) bool {
@@ -11,6 +11,7 @@ func TestIsLegalLevel(t *testing.T) {
 	assert.True(t, isLegalLevel("info"))
 	assert.True(t, isLegalLevel("warning"))
 	assert.True(t, isLegalLevel("error"))
+	assert.True(t, isLegalLevel("panic"))
 	assert.True(t, isLegalLevel("fatal"))
 	assert.False(t, isLegalLevel("warn"))
 	assert.False(t, isLegalLevel("trace"))
@@ -20,6 +21,69 @@ func TestIsLegalLevel(t *testing.T) {
 func TestIsLegalEncoding(t *testing.T) {
 	assert.True(t, isLegalEncoding("json"))
 	assert.True(t, isLegalEncoding("console"))
+	assert.True(t, isLegalEncoding("logfmt"))
+	assert.True(t, isLegalEncoding("syslog"))
+	assert.True(t, isLegalEncoding("journald"))
+	assert.True(t, isLegalEncoding("gelf"))
+	assert.True(t, isLegalEncoding("fluent"))
+	assert.True(t, isLegalEncoding("otlp"))
+	assert.True(t, isLegalEncoding("http"))
+	assert.True(t, isLegalEncoding("msgpack"))
+	assert.True(t, isLegalEncoding("cbor"))
 	assert.False(t, isLegalEncoding("file"))
 	assert.False(t, isLegalEncoding("yaml"))
 }
+
+func TestPostLoadSyslog(t *testing.T) {
+	c := &Config{LogLevel: "info", LogEncoding: "syslog", LogSyslogFormat: "RFC3164", LogSyslogFacility: "Local0"}
+	assert.Nil(t, c.PostLoad())
+	assert.Equal(t, "rfc3164", c.LogSyslogFormat)
+	assert.Equal(t, "local0", c.LogSyslogFacility)
+
+	c = &Config{LogLevel: "info", LogEncoding: "syslog", LogSyslogFormat: "rfc1234", LogSyslogFacility: "user"}
+	assert.Equal(t, ErrIllegalSyslogFormat, c.PostLoad())
+
+	c = &Config{LogLevel: "info", LogEncoding: "syslog", LogSyslogFormat: "rfc5424", LogSyslogFacility: "local8"}
+	assert.Equal(t, ErrIllegalSyslogFacility, c.PostLoad())
+}
+
+func TestPostLoadJSONProfile(t *testing.T) {
+	c := &Config{LogLevel: "info", LogEncoding: "json", LogJSONProfile: "GCP"}
+	assert.Nil(t, c.PostLoad())
+	assert.Equal(t, "gcp", c.LogJSONProfile)
+
+	c = &Config{LogLevel: "info", LogEncoding: "json", LogJSONProfile: "azure"}
+	assert.Equal(t, ErrIllegalJSONProfile, c.PostLoad())
+}
+
+func TestPostLoadGELFChunkSize(t *testing.T) {
+	for _, chunkSize := range []int{0, 13} {
+		c := &Config{LogLevel: "info", LogEncoding: "gelf", LogGELFAddress: "udp://localhost:12201", LogGELFChunkSize: chunkSize}
+		assert.Nil(t, c.PostLoad())
+	}
+
+	for _, chunkSize := range []int{-1, 12} {
+		c := &Config{LogLevel: "info", LogEncoding: "gelf", LogGELFAddress: "udp://localhost:12201", LogGELFChunkSize: chunkSize}
+		assert.Equal(t, ErrIllegalGELFChunkSize, c.PostLoad())
+	}
+}
+
+func TestPostLoadTimezone(t *testing.T) {
+	for _, timezone := range []string{"UTC", "local", "America/New_York"} {
+		c := &Config{LogLevel: "info", LogEncoding: "json", LogTimezone: timezone}
+		assert.Nil(t, c.PostLoad())
+	}
+
+	c := &Config{LogLevel: "info", LogEncoding: "json", LogTimezone: "Mars/Olympus_Mons"}
+	assert.Equal(t, ErrIllegalTimezone, c.PostLoad())
+}
+
+func TestPostLoadOutputDefaults(t *testing.T) {
+	for _, encoding := range []string{"fluent", "otlp"} {
+		c := &Config{LogLevel: "info", LogEncoding: encoding}
+		assert.Nil(t, c.PostLoad())
+	}
+
+	assert.Equal(t, DefaultFluentAddress, fluentAddress(&Config{}))
+	assert.Equal(t, "unix:///tmp/fluent.sock", fluentAddress(&Config{LogFluentAddress: "unix:///tmp/fluent.sock"}))
+}
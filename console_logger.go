@@ -4,7 +4,6 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"os"
 	"text/template"
 	"time"
 )
@@ -15,15 +14,40 @@ type consoleLogger struct {
 	stream    io.Writer
 }
 
-func newConsoleLogger(templates map[LogLevel]*template.Template, colorize bool) *consoleLogger {
+func newConsoleLogger(templates map[LogLevel]*template.Template, colorize bool, stream io.Writer) *consoleLogger {
 	return &consoleLogger{
 		templates: templates,
 		colorize:  colorize,
-		stream:    os.Stderr,
+		stream:    stream,
 	}
 }
 
+// consoleField is a single field passed to the console template.
+type consoleField struct {
+	Key   string
+	Value interface{}
+}
+
+// consoleFields returns the given fields in the order of the given keys.
+func consoleFields(fields LogFields, keys []string) []consoleField {
+	ordered := make([]consoleField, 0, len(keys))
+	for _, key := range keys {
+		value := fields[key]
+		if bundle, ok := value.([]LogFields); ok {
+			value = textValue(bundle)
+		}
+
+		ordered = append(ordered, consoleField{Key: key, Value: value})
+	}
+
+	return ordered
+}
+
 func (l *consoleLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	return l.LogOrdered(timestamp, level, fields, sortedFieldKeys(fields), msg)
+}
+
+func (l *consoleLogger) LogOrdered(timestamp time.Time, level LogLevel, fields LogFields, keys []string, msg string) error {
 	if !l.colorize {
 		level = LevelNone
 	}
@@ -34,7 +58,7 @@ func (l *consoleLogger) Log(timestamp time.Time, level LogLevel, fields LogField
 		"level":     level,
 		"levelName": level.String(),
 		"message":   msg,
-		"fields":    fields,
+		"fields":    consoleFields(fields, keys),
 	})
 
 	if err != nil {
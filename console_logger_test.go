@@ -15,12 +15,10 @@ func TestConsoleLoggerrLevel(t *testing.T) {
 	require.Nil(t, err)
 
 	templates := map[LogLevel]*template.Template{LevelInfo: parsed}
-	logger := newConsoleLogger(templates, true)
 	buffer := bytes.NewBuffer(nil)
+	logger := newConsoleLogger(templates, true, buffer)
 	timestamp := time.Unix(1503939881, 0)
 
-	logger.stream = buffer
-
 	logger.Log(
 		timestamp,
 		LevelInfo,
@@ -36,12 +34,10 @@ func TestConsoleLoggerColorDisabled(t *testing.T) {
 	require.Nil(t, err)
 
 	templates := map[LogLevel]*template.Template{LevelNone: parsed}
-	logger := newConsoleLogger(templates, false)
 	buffer := bytes.NewBuffer(nil)
+	logger := newConsoleLogger(templates, false, buffer)
 	timestamp := time.Unix(1503939881, 0)
 
-	logger.stream = buffer
-
 	logger.Log(
 		timestamp,
 		LevelInfo,
@@ -51,3 +47,18 @@ func TestConsoleLoggerColorDisabled(t *testing.T) {
 
 	assert.Equal(t, "test: test 1234\n", string(buffer.Bytes()))
 }
+
+func TestConsoleLoggerOrderedFields(t *testing.T) {
+	templates, err := newConsoleTemplate(newTimestampFormat("", consoleTimeLayout(true)), true, false, nil)
+	require.Nil(t, err)
+
+	buffer := bytes.NewBuffer(nil)
+	logger := newConsoleLogger(templates, false, buffer)
+	timestamp := time.Unix(1503939881, 0).UTC()
+	fields := LogFields{"b": 1, "a": 2, "c": 3}
+
+	logger.LogOrdered(timestamp, LevelInfo, fields, []string{"c", "a", "b"}, "test 1234")
+	logger.Log(timestamp, LevelInfo, fields, "test 1234")
+
+	assert.Equal(t, "[U] [17:04:41] test 1234 c=3 a=2 b=1\n[U] [17:04:41] test 1234 a=2 b=1 c=3\n", buffer.String())
+}
@@ -6,13 +6,49 @@ type loggerKeyType struct{}
 
 var loggerKey = loggerKeyType{}
 
+type fieldsKeyType struct{}
+
+var fieldsKey = fieldsKeyType{}
+
 func WithLogger(ctx context.Context, logger Logger) context.Context {
 	return context.WithValue(ctx, loggerKey, logger)
 }
 
+// FromContext returns the logger stored in the given context via WithLogger, or a
+// nil logger if no logger is stored. If the context carries fields added via
+// AddFieldsToContext, the returned logger is the result of calling WithFields on the
+// stored logger, so it is a different value than the stored logger. A ReplayLogger
+// remains a ReplayLogger. Context fields take precedence over fields of the same name
+// attached to the stored logger, and fields passed when logging a message take
+// precedence over both. If the context carries no fields, the stored logger is
+// returned unchanged.
 func FromContext(ctx context.Context) Logger {
-	if v, ok := ctx.Value(loggerKey).(Logger); ok {
-		return v
+	logger, ok := ctx.Value(loggerKey).(Logger)
+	if !ok {
+		logger = NewNilLogger()
+	}
+
+	fields, _ := ctx.Value(fieldsKey).(LogFields)
+	if len(fields) == 0 {
+		return logger
 	}
-	return NewNilLogger()
+
+	return logger.WithFields(fields.clone())
+}
+
+// AddFieldsToContext returns a context carrying the given fields in addition to
+// the fields already carried by the given context. Fields added later take
+// precedence over fields of the same name added earlier.
+func AddFieldsToContext(ctx context.Context, fields LogFields) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, fieldsKey, FieldsFromContext(ctx).concat(fields))
+}
+
+// FieldsFromContext returns a copy of the fields carried by the given context.
+func FieldsFromContext(ctx context.Context) LogFields {
+	fields, _ := ctx.Value(fieldsKey).(LogFields)
+	return fields.clone()
 }
@@ -9,6 +9,7 @@ import (
 
 	"github.com/derision-test/glock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWithAndFromContext(t *testing.T) {
@@ -17,10 +18,9 @@ func TestWithAndFromContext(t *testing.T) {
 		assert.Equal(t, NewNilLogger(), FromContext(ctx))
 	})
 	t.Run("from context that has value", func(t *testing.T) {
-		sink := newJSONLogger(nil)
 		buffer := bytes.NewBuffer(nil)
+		sink := newJSONLogger(nil, buffer)
 		timestamp := time.Unix(1628115072, 0)
-		sink.stream = buffer
 
 		clock := glock.NewMockClockAt(timestamp)
 		logger := newTestLogger(sink, LevelDebug, nil, clock, func() {})
@@ -43,3 +43,78 @@ func TestWithAndFromContext(t *testing.T) {
 		assert.Equal(t, "test 1234", logItem.Message)
 	})
 }
+
+func TestAddFieldsToContext(t *testing.T) {
+	t.Run("merges fields", func(t *testing.T) {
+		ctx := context.Background()
+		ctx = AddFieldsToContext(ctx, LogFields{"tenant": "a", "job": 1})
+		ctx = AddFieldsToContext(ctx, LogFields{"job": 2})
+		ctx = AddFieldsToContext(ctx, nil)
+
+		assert.Equal(t, LogFields{"tenant": "a", "job": 2}, FieldsFromContext(ctx))
+		assert.Equal(t, LogFields{}, FieldsFromContext(context.Background()))
+	})
+
+	t.Run("parent context is unchanged", func(t *testing.T) {
+		parent := AddFieldsToContext(context.Background(), LogFields{"tenant": "a"})
+		AddFieldsToContext(parent, LogFields{"tenant": "b"})
+
+		assert.Equal(t, LogFields{"tenant": "a"}, FieldsFromContext(parent))
+	})
+
+	t.Run("from context applies fields", func(t *testing.T) {
+		sink := NewMockLogSink()
+		clock := glock.NewMockClock()
+		logger := newTestLogger(sink, LevelDebug, LogFields{"tenant": "init", "service": "api"}, clock, func() {})
+
+		ctx := WithLogger(context.Background(), logger)
+		ctx = AddFieldsToContext(ctx, LogFields{"tenant": "a", "job": 1})
+
+		FromContext(ctx).InfoWithFields(LogFields{"job": 2}, "test")
+
+		require.Len(t, sink.LogFunc.History(), 1)
+		fields := sink.LogFunc.History()[0].Arg2
+		assert.Equal(t, "api", fields["service"])
+		assert.Equal(t, "a", fields["tenant"])
+		assert.Equal(t, 2, fields["job"])
+	})
+
+	t.Run("fields added before logger is stored", func(t *testing.T) {
+		sink := NewMockLogSink()
+		clock := glock.NewMockClock()
+		logger := newTestLogger(sink, LevelDebug, nil, clock, func() {})
+
+		ctx := AddFieldsToContext(context.Background(), LogFields{"tenant": "a"})
+		ctx = WithLogger(ctx, logger)
+
+		FromContext(ctx).Info("test")
+
+		require.Len(t, sink.LogFunc.History(), 1)
+		assert.Equal(t, "a", sink.LogFunc.History()[0].Arg2["tenant"])
+	})
+
+	t.Run("without fields returns stored logger", func(t *testing.T) {
+		logger := newTestLogger(NewMockLogSink(), LevelDebug, nil, glock.NewMockClock(), func() {})
+		ctx := WithLogger(context.Background(), logger)
+		ctx = AddFieldsToContext(ctx, nil)
+
+		assert.Same(t, logger, FromContext(ctx))
+	})
+
+	t.Run("replay logger with fields", func(t *testing.T) {
+		sink := NewMockLogSink()
+		logger := newTestLogger(sink, LevelInfo, nil, glock.NewMockClock(), func() {})
+		ctx := WithLogger(context.Background(), NewReplayLogger(logger, LevelDebug))
+		ctx = AddFieldsToContext(ctx, LogFields{"tenant": "a"})
+
+		replayLogger, ok := FromContext(ctx).(ReplayLogger)
+		require.True(t, ok)
+
+		replayLogger.Debug("test")
+		replayLogger.Replay(LevelWarning)
+
+		require.Len(t, sink.LogFunc.History(), 1)
+		assert.Equal(t, LevelWarning, sink.LogFunc.History()[0].Arg1)
+		assert.Equal(t, "a", sink.LogFunc.History()[0].Arg2["tenant"])
+	})
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultFatalHookTimeout is the default maximum duration for which fatal hooks are
+// run before the process exits.
+const DefaultFatalHookTimeout = 5 * time.Second
+
+// FatalHook is a function invoked after a message is logged at the fatal level and
+// before the process exits. The given context is canceled once the fatal hook timeout
+// elapses, after which the process exits regardless of whether the hooks have returned.
+type FatalHook func(ctx context.Context)
+
+var (
+	fatalHooks      = map[int]FatalHook{}
+	fatalHooksID    int
+	fatalHooksMutex sync.RWMutex
+)
+
+// RegisterFatalHook registers a hook that is invoked by every logger after a message
+// is logged at the fatal level, after any hooks supplied via WithFatalHook. Hooks are
+// invoked in the order they were registered. The returned function unregisters the hook.
+func RegisterFatalHook(hook FatalHook) func() {
+	fatalHooksMutex.Lock()
+	defer fatalHooksMutex.Unlock()
+
+	fatalHooksID++
+	id := fatalHooksID
+	fatalHooks[id] = hook
+
+	return func() {
+		fatalHooksMutex.Lock()
+		delete(fatalHooks, id)
+		fatalHooksMutex.Unlock()
+	}
+}
+
+func registeredFatalHooks() []FatalHook {
+	fatalHooksMutex.RLock()
+	defer fatalHooksMutex.RUnlock()
+
+	hooks := make([]FatalHook, 0, len(fatalHooks))
+	for id := 1; id <= fatalHooksID; id++ {
+		if hook, ok := fatalHooks[id]; ok {
+			hooks = append(hooks, hook)
+		}
+	}
+
+	return hooks
+}
+
+// runFatalHooks invokes each of the given hooks in order, returning once all hooks
+// have returned or the timeout elapses. A panic in one hook does not prevent the
+// remaining hooks from running.
+func runFatalHooks(hooks []FatalHook, timeout time.Duration) {
+	if len(hooks) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for _, hook := range hooks {
+			func() {
+				defer func() { _ = recover() }()
+				hook(ctx)
+			}()
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
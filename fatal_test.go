@@ -0,0 +1,138 @@
+package log
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type syncingLogSink struct {
+	*MockLogSink
+	synced int
+}
+
+func (s *syncingLogSink) Sync() error {
+	s.synced++
+	return nil
+}
+
+func TestFatalHooks(t *testing.T) {
+	sink := &syncingLogSink{MockLogSink: NewMockLogSink()}
+	var calls []string
+
+	unregister := RegisterFatalHook(func(ctx context.Context) { calls = append(calls, "global") })
+	defer unregister()
+
+	logger := newBaseLogger(sink, LevelInfo, nil, timestampOptions{}, fieldOrder{}, getLoggerOptions([]LoggerConfigFunc{
+		WithFatalHook(func(ctx context.Context) { calls = append(calls, "first") }),
+		WithFatalHook(func(ctx context.Context) { panic("oops") }),
+		WithFatalHook(func(ctx context.Context) { calls = append(calls, "second") }),
+		WithExiter(func() { calls = append(calls, "exit") }),
+	}))
+
+	logger.Fatal("test")
+	assert.Equal(t, []string{"first", "second", "global", "exit"}, calls)
+	assert.Equal(t, 1, sink.synced)
+	assert.Len(t, sink.LogFunc.History(), 1)
+
+	unregister()
+	calls = nil
+	logger.Fatal("test")
+	assert.Equal(t, []string{"first", "second", "exit"}, calls)
+}
+
+func TestFatalHookTimeout(t *testing.T) {
+	sink := NewMockLogSink()
+	exited := make(chan struct{})
+	block := make(chan struct{})
+	defer close(block)
+
+	logger := newBaseLogger(sink, LevelInfo, nil, timestampOptions{}, fieldOrder{}, getLoggerOptions([]LoggerConfigFunc{
+		WithFatalHookTimeout(10 * time.Millisecond),
+		WithFatalHook(func(ctx context.Context) { <-block }),
+		WithExiter(func() { close(exited) }),
+	}))
+
+	logger.Fatal("test")
+
+	select {
+	case <-exited:
+	case <-time.After(time.Second):
+		t.Fatalf("expected exit after fatal hook timeout")
+	}
+}
+
+func TestFatalHookContextCanceledAtTimeout(t *testing.T) {
+	sink := NewMockLogSink()
+	canceled := make(chan error, 1)
+
+	logger := newBaseLogger(sink, LevelInfo, nil, timestampOptions{}, fieldOrder{}, getLoggerOptions([]LoggerConfigFunc{
+		WithFatalHookTimeout(10 * time.Millisecond),
+		WithFatalHook(func(ctx context.Context) { <-ctx.Done(); canceled <- ctx.Err() }),
+		WithExiter(func() {}),
+	}))
+
+	logger.Fatal("test")
+	require.Equal(t, context.DeadlineExceeded, <-canceled)
+}
+
+func TestPanicLevel(t *testing.T) {
+	sink := &syncingLogSink{MockLogSink: NewMockLogSink()}
+	exited := false
+	logger := newTestLogger(sink, LevelError, nil, glock.NewMockClock(), func() { exited = true })
+
+	assert.PanicsWithValue(t, "test 1 2", func() {
+		PanicWithFields(logger, LogFields{"x": "y"}, "test %d %d", 1, 2)
+	})
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelPanic, history[0].Arg1)
+	assert.Equal(t, "y", history[0].Arg2["x"])
+	assert.Equal(t, 1, sink.synced)
+	assert.False(t, exited)
+}
+
+func TestPanicLevelFiltered(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelFatal, nil, glock.NewMockClock(), func() {})
+
+	assert.PanicsWithValue(t, "boom", func() { Panic(logger, "boom") })
+	assert.Empty(t, sink.LogFunc.History())
+}
+
+// plainLogger hides the PanicLogger methods of the wrapped logger.
+type plainLogger struct {
+	Logger
+}
+
+func (l plainLogger) WithIndirectCaller(frames int) Logger {
+	return plainLogger{l.Logger.WithIndirectCaller(frames)}
+}
+
+func TestPanicWithoutPanicLogger(t *testing.T) {
+	logger := &testLogger{}
+
+	assert.PanicsWithValue(t, "test 1", func() { Panic(plainLogger{FromMinimalLogger(logger)}, "test %d", 1) })
+
+	messages := logger.copy()
+	require.Len(t, messages, 1)
+	assert.Equal(t, LevelPanic, messages[0].level)
+}
+
+func TestAdapterPanics(t *testing.T) {
+	assert.PanicsWithValue(t, "boom", func() { NewNilLogger().(PanicLogger).Panic("boom") })
+
+	logger := &testLogger{}
+	assert.PanicsWithValue(t, "test 1", func() {
+		FromMinimalLogger(logger).(PanicLogger).PanicWithFields(LogFields{"x": "y"}, "test %d", 1)
+	})
+
+	messages := logger.copy()
+	require.Len(t, messages, 1)
+	assert.Equal(t, LevelPanic, messages[0].level)
+}
@@ -1,6 +1,9 @@
 package log
 
-import "time"
+import (
+	"sort"
+	"time"
+)
 
 type LogFields map[string]interface{}
 
@@ -34,3 +37,99 @@ func (f LogFields) normalizeTimeValues() LogFields {
 
 	return f
 }
+
+// formatTimeValues replaces time values in-place with their representation in the
+// given format and timezone.
+func (f LogFields) formatTimeValues(format timestampFormat, location *time.Location) LogFields {
+	for key, val := range f {
+		if v, ok := val.(time.Time); ok {
+			f[key] = format.value(v.In(location))
+		}
+	}
+
+	return f
+}
+
+// fieldOrder determines the order in which fields are written by encodings that
+// preserve it. Keys in the priority list are written first, followed by the
+// remaining keys either in the order they were added to the logger or sorted.
+type fieldOrder struct {
+	priority  []string
+	insertion bool
+}
+
+// isDefault returns true if fields are sorted and no keys are prioritized.
+func (o fieldOrder) isDefault() bool {
+	return len(o.priority) == 0 && !o.insertion
+}
+
+// keys returns the keys of the given fields in order. The inserted keys give the
+// order in which keys were added to the logger. Keys that were not tracked are
+// written last in sorted order.
+func (o fieldOrder) keys(fields LogFields, inserted []string) []string {
+	keys := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+
+	add := func(key string) {
+		if _, ok := fields[key]; !ok {
+			return
+		}
+		if _, ok := seen[key]; ok {
+			return
+		}
+
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	for _, key := range o.priority {
+		add(key)
+	}
+
+	if o.insertion {
+		for _, key := range inserted {
+			add(key)
+		}
+	}
+
+	for _, key := range sortedFieldKeys(fields) {
+		add(key)
+	}
+
+	return keys
+}
+
+// appendFieldKeys returns the given keys followed by the keys of fields that are
+// not yet present, in sorted order. The caller and sequence number fields added by
+// the logger are not tracked so that they follow the fields added by the user. The
+// given slice is never modified.
+func appendFieldKeys(keys []string, fields LogFields) []string {
+	keys = keys[:len(keys):len(keys)]
+
+outer:
+	for _, key := range sortedFieldKeys(fields) {
+		if key == "caller" || key == fieldSequenceNumber {
+			continue
+		}
+
+		for _, existing := range keys {
+			if existing == key {
+				continue outer
+			}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+func sortedFieldKeys(fields LogFields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
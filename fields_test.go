@@ -1,10 +1,13 @@
 package log
 
 import (
+	"bytes"
 	"testing"
 	"time"
 
+	"github.com/derision-test/glock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFieldsNormalizeTimeValues(t *testing.T) {
@@ -37,3 +40,55 @@ func TestFieldsNormalizeTimeValues(t *testing.T) {
 func TestFieldsNormalizeTimeValuesOnNilFields(t *testing.T) {
 	assert.Nil(t, LogFields(nil).normalizeTimeValues())
 }
+
+func TestFieldOrderKeys(t *testing.T) {
+	fields := LogFields{"b": 1, "a": 2, "caller": "x", "request_id": "r", "c": 3}
+
+	assert.Equal(t, []string{"a", "b", "c", "caller", "request_id"}, fieldOrder{}.keys(fields, nil))
+	assert.Equal(t, []string{"request_id", "caller", "a", "b", "c"}, fieldOrder{priority: []string{"request_id", "missing", "caller"}}.keys(fields, nil))
+	assert.Equal(t, []string{"c", "a", "b", "caller", "request_id"}, fieldOrder{insertion: true}.keys(fields, []string{"c", "a"}))
+	assert.Equal(t, []string{"request_id", "c", "a", "b", "caller"}, fieldOrder{priority: []string{"request_id"}, insertion: true}.keys(fields, []string{"c", "request_id", "a"}))
+}
+
+func TestAppendFieldKeys(t *testing.T) {
+	keys := appendFieldKeys(nil, LogFields{"b": 1, "a": 2})
+	assert.Equal(t, []string{"a", "b"}, keys)
+
+	// Existing keys keep their position and logger-assigned keys are not tracked
+	child1 := appendFieldKeys(keys, LogFields{"d": 1, "a": 3, "caller": "x", fieldSequenceNumber: 1})
+	child2 := appendFieldKeys(keys, LogFields{"c": 1})
+	assert.Equal(t, []string{"a", "b", "d"}, child1)
+	assert.Equal(t, []string{"a", "b", "c"}, child2)
+	assert.Equal(t, []string{"a", "b"}, keys)
+}
+
+func TestInitLoggerFieldOrder(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "logfmt", LogFieldOrder: "insertion", LogFieldPriority: []string{"request_id"}},
+		WithOutput(buffer),
+		WithClock(glock.NewMockClock()),
+		WithSequenceSource(func() uint64 { return 1 }),
+	)
+	require.Nil(t, err)
+
+	logger.WithFields(LogFields{"zone": "z", "request_id": "r"}).WithFields(LogFields{"app": "a"}).InfoWithFields(LogFields{"b": 1, "a": 2}, "test 1234")
+
+	line := buffer.String()
+	assert.Regexp(t, `message="test 1234" request_id=r zone=z app=a a=2 b=1 caller=\S+ sequenceNumber=1\n$`, line)
+}
+
+func TestInitLoggerFieldOrderJSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "json", LogFieldPriority: []string{"request_id", "caller"}},
+		WithOutput(buffer),
+		WithClock(glock.NewMockClockAt(time.Unix(1503939881, 0))),
+		WithSequenceSource(func() uint64 { return 1 }),
+	)
+	require.Nil(t, err)
+
+	logger.InfoWithFields(LogFields{"b": 1, "request_id": "r", "a": []int{1}}, "test 1234")
+
+	assert.Regexp(t, `^\{"timestamp":"2017-08-28T17:04:41.000\+0000","level":"info","message":"test 1234","request_id":"r","caller":"[^"]+","a":\[1\],"b":1,"sequenceNumber":1\}\n$`, buffer.String())
+}
@@ -0,0 +1,226 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+// DefaultFluentAddress is the default address of a local Fluentd or Fluent Bit
+// forward input.
+const DefaultFluentAddress = "tcp://localhost:24224"
+
+// FieldFluentTag is the field that overrides the tag of messages written with the
+// fluent encoding. Use WithFields to set the tag for all messages of a logger.
+const FieldFluentTag = "fluent-tag"
+
+// ErrFluentAck is returned when a Fluentd server does not acknowledge a chunk.
+var ErrFluentAck = fmt.Errorf("fluent chunk was not acknowledged")
+
+// fluentEventTimeExt is the extension type used by the Forward protocol for
+// timestamps with nanosecond precision.
+const fluentEventTimeExt = 0
+
+type fluentLogger struct {
+	dial       func() (net.Conn, error)
+	tag        string
+	requireAck bool
+	queue      *batchQueue
+	conn       net.Conn
+	decoder    *msgpackDecoder
+	mutex      sync.Mutex
+}
+
+func newFluentLogger(c *Config, clock glock.Clock) (*fluentLogger, error) {
+	dial, err := newStreamDialer(fluentAddress(c), c)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &fluentLogger{
+		dial:       dial,
+		tag:        c.LogFluentTag,
+		requireAck: c.LogFluentRequireAck,
+	}
+
+	l.queue = newBatchQueue(c, clock, l.send)
+	l.queue.onDrop = reportDrops(l, clock)
+
+	return l, nil
+}
+
+func fluentAddress(c *Config) string {
+	if c.LogFluentAddress == "" {
+		return DefaultFluentAddress
+	}
+
+	return c.LogFluentAddress
+}
+
+// Log encodes the message as a Forward protocol entry and adds it to the batch of
+// its tag. The message, level, and fields are written to the record.
+func (l *fluentLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	tag := l.tag
+	record := make(map[string]interface{}, len(fields)+2)
+
+	for key, value := range fields {
+		if key == FieldFluentTag {
+			tag = fmt.Sprintf("%v", value)
+			continue
+		}
+
+		record[key] = value
+	}
+
+	record["message"] = msg
+	record["level"] = level.String()
+
+	entry := appendMsgpackArrayHeader(nil, 2)
+	entry = appendFluentEventTime(entry, timestamp)
+	entry = appendMsgpack(entry, record)
+
+	l.queue.Add(tag, entry)
+	return nil
+}
+
+func (l *fluentLogger) Sync() error {
+	return l.queue.Sync()
+}
+
+// Close sends all buffered messages, stops the background goroutine, and closes
+// the connection.
+func (l *fluentLogger) Close() error {
+	l.queue.Close()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conn == nil {
+		return nil
+	}
+
+	err := l.conn.Close()
+	l.conn = nil
+	l.decoder = nil
+	return err
+}
+
+// send writes a batch as one PackedForward message per tag. On error the connection
+// is discarded so that the next attempt reconnects, and the tags that were already
+// sent are not retried.
+func (l *fluentLogger) send(entries []batchEntry) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.conn == nil {
+		conn, err := l.dial()
+		if err != nil {
+			return err
+		}
+
+		l.conn = conn
+		l.decoder = newMsgpackDecoder(conn)
+	}
+
+	groups := groupBatchEntries(entries)
+	for i, group := range groups {
+		if err := l.sendPackedForward(group); err != nil {
+			l.conn.Close()
+			l.conn = nil
+			l.decoder = nil
+
+			if i == 0 {
+				return err
+			}
+
+			var unsent []batchEntry
+			for _, group := range groups[i:] {
+				unsent = append(unsent, group...)
+			}
+
+			return &batchSendError{err: err, unsent: unsent}
+		}
+	}
+
+	return nil
+}
+
+func (l *fluentLogger) sendPackedForward(entries []batchEntry) error {
+	var packed []byte
+	for _, entry := range entries {
+		packed = append(packed, entry.data...)
+	}
+
+	option := map[string]interface{}{"size": len(entries)}
+
+	var chunk string
+	if l.requireAck {
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return err
+		}
+
+		chunk = base64.StdEncoding.EncodeToString(id)
+		option["chunk"] = chunk
+	}
+
+	message := appendMsgpackArrayHeader(nil, 3)
+	message = appendMsgpackString(message, entries[0].key)
+	message = appendMsgpackBinary(message, packed)
+	message = appendMsgpack(message, option)
+
+	l.conn.SetDeadline(time.Now().Add(streamWriteTimeout))
+
+	if _, err := l.conn.Write(message); err != nil {
+		return err
+	}
+
+	if !l.requireAck {
+		return nil
+	}
+
+	response, err := l.decoder.Decode()
+	if err != nil {
+		return err
+	}
+
+	if m, ok := response.(map[string]interface{}); !ok || m["ack"] != chunk {
+		return ErrFluentAck
+	}
+
+	return nil
+}
+
+// groupBatchEntries partitions entries by key. Groups are ordered by the first
+// occurrence of their key, and entries retain their relative order within a group.
+func groupBatchEntries(entries []batchEntry) [][]batchEntry {
+	var groups [][]batchEntry
+	indexes := map[string]int{}
+
+	for _, entry := range entries {
+		index, ok := indexes[entry.key]
+		if !ok {
+			index = len(groups)
+			indexes[entry.key] = index
+			groups = append(groups, nil)
+		}
+
+		groups[index] = append(groups[index], entry)
+	}
+
+	return groups
+}
+
+// appendFluentEventTime encodes a timestamp as a Forward protocol EventTime, which
+// holds the seconds and nanoseconds as big-endian 32-bit integers.
+func appendFluentEventTime(buf []byte, t time.Time) []byte {
+	data := binary.BigEndian.AppendUint32(nil, uint32(t.Unix()))
+	data = binary.BigEndian.AppendUint32(data, uint32(t.Nanosecond()))
+	return appendMsgpackExt(buf, fluentEventTimeExt, data)
+}
@@ -0,0 +1,143 @@
+package log
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFluentLoggerPackedForward(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := newFluentLogger(&Config{
+		LogFluentAddress: "tcp://" + listener.Addr().String(),
+		LogFluentTag:     "app",
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	timestamp := time.Unix(1503939881, 123456789)
+	require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"attr1": 4321}, "A"))
+	require.Nil(t, logger.Log(timestamp, LevelWarning, LogFields{FieldFluentTag: "app.access"}, "B"))
+	require.Nil(t, logger.Log(timestamp, LevelError, nil, "C"))
+
+	go logger.Sync()
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+	decoder := newMsgpackDecoder(conn)
+
+	tag, entries, option := readFluentMessage(t, decoder)
+	assert.Equal(t, "app", tag)
+	assert.Equal(t, map[string]interface{}{"size": int64(2)}, option)
+	require.Len(t, entries, 2)
+
+	eventTime := entries[0][0].(msgpackExt)
+	assert.Equal(t, int8(fluentEventTimeExt), eventTime.Type)
+	assert.Equal(t, []byte{0x59, 0xa4, 0x4d, 0x29, 0x07, 0x5b, 0xcd, 0x15}, eventTime.Data)
+	assert.Equal(t, map[string]interface{}{"message": "A", "level": "info", "attr1": int64(4321)}, entries[0][1])
+	assert.Equal(t, map[string]interface{}{"message": "C", "level": "error"}, entries[1][1])
+
+	tag, entries, _ = readFluentMessage(t, decoder)
+	assert.Equal(t, "app.access", tag)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]interface{}{"message": "B", "level": "warning"}, entries[0][1])
+}
+
+func TestFluentLoggerAck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := newFluentLogger(&Config{
+		LogFluentAddress:    "tcp://" + listener.Addr().String(),
+		LogFluentTag:        "app",
+		LogFluentRequireAck: true,
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	errs := make(chan error, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			errs <- err
+			return
+		}
+		defer conn.Close()
+
+		decoder := newMsgpackDecoder(conn)
+		for i := 0; i < 2; i++ {
+			message, err := decoder.Decode()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			option := message.([]interface{})[2].(map[string]interface{})
+			if _, err := conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": option["chunk"]})); err != nil {
+				errs <- err
+				return
+			}
+		}
+
+		errs <- nil
+	}()
+
+	// Both batches are acknowledged on the same connection
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, "A"))
+	require.Nil(t, logger.Sync())
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, "B"))
+	require.Nil(t, logger.Sync())
+	require.Nil(t, <-errs)
+}
+
+func TestFluentLoggerMissingAck(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			newMsgpackDecoder(conn).Decode()
+			conn.Write(appendMsgpack(nil, map[string]interface{}{"ack": "wrong"}))
+		}
+	}()
+
+	logger, err := newFluentLogger(&Config{
+		LogFluentAddress:    "tcp://" + listener.Addr().String(),
+		LogFluentRequireAck: true,
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	assert.Equal(t, ErrFluentAck, logger.send([]batchEntry{{key: "app", data: appendMsgpack(nil, "x")}}))
+}
+
+func readFluentMessage(t *testing.T, decoder *msgpackDecoder) (string, [][]interface{}, map[string]interface{}) {
+	message, err := decoder.Decode()
+	require.Nil(t, err)
+
+	parts := message.([]interface{})
+	require.Len(t, parts, 3)
+
+	var entries [][]interface{}
+	entryDecoder := newMsgpackDecoder(bytes.NewReader(parts[1].([]byte)))
+	for {
+		entry, err := entryDecoder.Decode()
+		if err != nil {
+			break
+		}
+
+		entries = append(entries, entry.([]interface{}))
+	}
+
+	return parts[0].(string), entries, parts[2].(map[string]interface{})
+}
@@ -0,0 +1,184 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+const (
+	// DefaultGELFChunkSize is the maximum size of a UDP datagram sent to a GELF
+	// endpoint, unless configured otherwise.
+	DefaultGELFChunkSize = 1420
+
+	gelfChunkHeaderSize = 12
+	gelfMaxChunks       = 128
+)
+
+// ErrGELFMessageTooLarge is returned when a message does not fit into the maximum
+// number of GELF chunks.
+var ErrGELFMessageTooLarge = fmt.Errorf("message exceeds maximum GELF size")
+
+var gelfFieldPattern = regexp.MustCompile(`[^\w.\-]`)
+
+type gelfLogger struct {
+	host      string
+	stream    io.Writer
+	delimiter []byte
+}
+
+func newGELFLogger(c *Config, clock glock.Clock) (logSink, error) {
+	u, err := url.Parse(c.LogGELFAddress)
+	if err != nil {
+		return nil, ErrIllegalOutputAddress
+	}
+
+	host, _ := os.Hostname()
+
+	switch u.Scheme {
+	case "udp":
+		chunkSize := c.LogGELFChunkSize
+		if chunkSize == 0 {
+			chunkSize = DefaultGELFChunkSize
+		}
+		if chunkSize <= gelfChunkHeaderSize {
+			return nil, ErrIllegalGELFChunkSize
+		}
+
+		conn, err := net.Dial("udp", u.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		return &gelfLogger{host: host, stream: &gelfChunkWriter{conn: conn, chunkSize: chunkSize}}, nil
+
+	case "tcp":
+		dial := func() (net.Conn, error) { return net.DialTimeout("tcp", u.Host, streamWriteTimeout) }
+		writer := newStreamWriter(dial, c.LogOutputBufferSize, clock)
+
+		// Messages sent over TCP are delimited by a null byte
+		return newStreamLogger(&gelfLogger{host: host, stream: writer, delimiter: []byte{0}}, writer, clock), nil
+	}
+
+	return nil, ErrIllegalOutputAddress
+}
+
+func (l *gelfLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	payload, err := l.encode(timestamp, level, fields, msg)
+	if err != nil {
+		return err
+	}
+
+	_, err = l.stream.Write(append(payload, l.delimiter...))
+	return err
+}
+
+func (l *gelfLogger) Close() error {
+	if closer, ok := l.stream.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return nil
+}
+
+// encode serializes a message as a GELF 1.1 payload. Only the first line of a
+// multi-line message is used as the short message. Log fields are encoded as
+// additional fields.
+func (l *gelfLogger) encode(timestamp time.Time, level LogLevel, fields LogFields, msg string) ([]byte, error) {
+	payload := map[string]interface{}{
+		"version":       "1.1",
+		"host":          l.host,
+		"short_message": msg,
+		"timestamp":     float64(timestamp.UnixNano()/int64(time.Millisecond)) / 1000,
+		"level":         syslogSeverity(level),
+	}
+
+	if index := strings.Index(msg, "\n"); index >= 0 {
+		payload["short_message"] = msg[:index]
+		payload["full_message"] = msg
+	}
+
+	for key, value := range fields {
+		payload[gelfFieldName(key)] = gelfFieldValue(value)
+	}
+
+	return json.Marshal(payload)
+}
+
+// gelfFieldName converts a log field key into a GELF additional field name. The
+// reserved name _id is renamed to _id_.
+func gelfFieldName(key string) string {
+	name := "_" + gelfFieldPattern.ReplaceAllString(key, "_")
+	if name == "_id" {
+		return "_id_"
+	}
+
+	return name
+}
+
+// gelfFieldValue returns the given value if it is a string or a number. All other
+// values are formatted as strings.
+func gelfFieldValue(value interface{}) interface{} {
+	switch value.(type) {
+	case string, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return value
+	}
+
+	return textValue(value)
+}
+
+// gelfChunkWriter writes messages to a UDP connection, splitting messages larger
+// than the chunk size into at most 128 chunks sharing a random message id.
+type gelfChunkWriter struct {
+	conn      net.Conn
+	chunkSize int
+}
+
+func (w *gelfChunkWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.chunkSize {
+		return w.conn.Write(p)
+	}
+
+	dataSize := w.chunkSize - gelfChunkHeaderSize
+	count := (len(p) + dataSize - 1) / dataSize
+	if count > gelfMaxChunks {
+		return 0, ErrGELFMessageTooLarge
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(p) {
+			end = len(p)
+		}
+
+		chunk := make([]byte, 0, gelfChunkHeaderSize+end-i*dataSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, p[i*dataSize:end]...)
+
+		if _, err := w.conn.Write(chunk); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+func (w *gelfChunkWriter) Close() error {
+	return w.conn.Close()
+}
@@ -0,0 +1,160 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGELFLoggerEncode(t *testing.T) {
+	logger := &gelfLogger{host: "host"}
+	timestamp := time.Unix(1503939881, 123456789)
+
+	payload, err := logger.encode(timestamp, LevelError, LogFields{
+		"attr1":   4321,
+		"id":      "abc",
+		"bad key": true,
+	}, "test 1234\nsecond line")
+	require.Nil(t, err)
+
+	assert.JSONEq(t, `{
+		"version": "1.1",
+		"host": "host",
+		"short_message": "test 1234",
+		"full_message": "test 1234\nsecond line",
+		"timestamp": 1503939881.123,
+		"level": 3,
+		"_attr1": 4321,
+		"_id_": "abc",
+		"_bad_key": "true"
+	}`, string(payload))
+}
+
+func TestGELFLoggerUDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:       "info",
+		LogEncoding:    "gelf",
+		LogGELFAddress: "udp://" + conn.LocalAddr().String(),
+	})
+	require.Nil(t, err)
+
+	logger.InfoWithFields(LogFields{"attr1": 4321}, "test 1234")
+
+	buffer := make([]byte, 2048)
+	n, _, err := conn.ReadFrom(buffer)
+	require.Nil(t, err)
+
+	data := map[string]interface{}{}
+	require.Nil(t, json.Unmarshal(buffer[:n], &data))
+	assert.Equal(t, "test 1234", data["short_message"])
+	assert.Equal(t, float64(6), data["level"])
+	assert.Equal(t, float64(4321), data["_attr1"])
+	assert.NotEmpty(t, data["_caller"])
+}
+
+func TestGELFLoggerUDPChunking(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.Nil(t, err)
+	defer client.Close()
+
+	writer := &gelfChunkWriter{conn: client, chunkSize: 100}
+	message := []byte(strings.Repeat("0123456789", 45))
+	n, err := writer.Write(message)
+	require.Nil(t, err)
+	assert.Equal(t, len(message), n)
+
+	// 450 bytes split into chunks of 88 bytes of data
+	var id []byte
+	var reassembled []byte
+	for i := 0; i < 6; i++ {
+		buffer := make([]byte, 2048)
+		n, _, err := conn.ReadFrom(buffer)
+		require.Nil(t, err)
+		chunk := buffer[:n]
+
+		require.LessOrEqual(t, len(chunk), 100)
+		assert.Equal(t, []byte{0x1e, 0x0f}, chunk[:2])
+		assert.Equal(t, byte(i), chunk[10])
+		assert.Equal(t, byte(6), chunk[11])
+
+		if id == nil {
+			id = chunk[2:10]
+		}
+		assert.Equal(t, id, chunk[2:10])
+
+		reassembled = append(reassembled, chunk[12:]...)
+	}
+
+	assert.Equal(t, message, reassembled)
+}
+
+func TestGELFLoggerUDPTooLarge(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	require.Nil(t, err)
+	defer client.Close()
+
+	writer := &gelfChunkWriter{conn: client, chunkSize: 13}
+	_, err = writer.Write(bytes.Repeat([]byte("x"), 129))
+	assert.Equal(t, ErrGELFMessageTooLarge, err)
+}
+
+func TestGELFLoggerTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	sink, err := newGELFLogger(&Config{LogGELFAddress: "tcp://" + listener.Addr().String()}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	timestamp := time.Unix(1503939881, 0)
+	require.Nil(t, sink.Log(timestamp, LevelWarning, nil, "A"))
+	require.Nil(t, sink.Log(timestamp, LevelWarning, nil, "B"))
+	require.Nil(t, sink.(syncer).Sync())
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for _, expected := range []string{"A", "B"} {
+		frame, err := reader.ReadBytes(0)
+		require.Nil(t, err)
+
+		data := map[string]interface{}{}
+		require.Nil(t, json.Unmarshal(frame[:len(frame)-1], &data))
+		assert.Equal(t, expected, data["short_message"])
+		assert.Equal(t, float64(4), data["level"])
+	}
+}
+
+func TestGELFLoggerIllegalAddress(t *testing.T) {
+	_, err := newGELFLogger(&Config{LogGELFAddress: "http://localhost:12201"}, glock.NewRealClock())
+	assert.Equal(t, ErrIllegalOutputAddress, err)
+}
+
+func TestGELFLoggerIllegalChunkSize(t *testing.T) {
+	for _, chunkSize := range []int{-1, 1, 12} {
+		_, err := newGELFLogger(&Config{LogGELFAddress: "udp://localhost:12201", LogGELFChunkSize: chunkSize}, glock.NewRealClock())
+		assert.Equal(t, ErrIllegalGELFChunkSize, err)
+	}
+}
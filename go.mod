@@ -1,13 +1,18 @@
 module github.com/go-nacelle/log/v2
 
-go 1.16
+go 1.21
 
 require (
 	github.com/derision-test/glock v0.0.0-20210316032053-f5b74334bb29
 	github.com/derision-test/go-mockgen v0.0.0-20201001011750-eb2233de6342
-	github.com/mattn/go-colorable v0.1.8 // indirect
-	github.com/mattn/go-isatty v0.0.13 // indirect
 	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d
-	github.com/stretchr/testify v1.6.1
-	golang.org/x/sys v0.0.0-20210531080801-fdfd190a6549 // indirect
+	github.com/stretchr/testify v1.12.1
+	golang.org/x/sys v0.30.0
+	google.golang.org/protobuf v1.36.5
+)
+
+require (
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
 )
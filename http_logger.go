@@ -0,0 +1,282 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+// ErrHTTPPush is returned when an HTTP endpoint rejects a batch of messages.
+var ErrHTTPPush = fmt.Errorf("HTTP push failed")
+
+// ErrIllegalHTTPFormat is returned when the configured HTTP payload format is unknown.
+var ErrIllegalHTTPFormat = fmt.Errorf("illegal HTTP format")
+
+// httpPayloadBuilder encodes messages and assembles batches of encoded messages into
+// the body of a single request.
+type httpPayloadBuilder interface {
+	// Encode returns the encoded message along with a key. Messages with the same key
+	// are grouped together by builders that support it.
+	Encode(timestamp time.Time, level LogLevel, fields LogFields, msg string) (key string, data []byte, err error)
+
+	// Build returns the request body for a batch of encoded messages and its
+	// content type.
+	Build(entries []batchEntry) (body []byte, contentType string, err error)
+}
+
+type httpLogger struct {
+	endpoint string
+	headers  map[string]string
+	gzip     bool
+	client   *http.Client
+	builder  httpPayloadBuilder
+	queue    *batchQueue
+}
+
+// newHTTPLogger creates a sink that pushes batches of messages to an HTTP endpoint.
+// The payload format is selected by LogHTTPFormat.
+func newHTTPLogger(c *Config, clock glock.Clock) (*httpLogger, error) {
+	builder, err := newHTTPPayloadBuilder(c)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &httpLogger{
+		endpoint: c.LogHTTPEndpoint,
+		headers:  c.LogHTTPHeaders,
+		gzip:     c.LogHTTPGzip,
+		client:   &http.Client{Timeout: streamWriteTimeout},
+		builder:  builder,
+	}
+
+	l.queue = newBatchQueue(c, clock, l.send)
+	l.queue.onDrop = reportDrops(l, clock)
+
+	return l, nil
+}
+
+func newHTTPPayloadBuilder(c *Config) (httpPayloadBuilder, error) {
+	switch c.LogHTTPFormat {
+	case "", "json":
+		return &jsonArrayPayloadBuilder{
+			fieldNames: c.LogJSONFieldNames,
+			timeFormat: newTimestampFormat(c.LogTimestampFormat, JSONTimeFormat),
+		}, nil
+	case "loki":
+		return &lokiPayloadBuilder{labels: c.LogLokiLabels}, nil
+	case "elasticsearch":
+		index := c.LogElasticsearchIndex
+		if index == "" {
+			index = "logs"
+		}
+
+		return &elasticsearchPayloadBuilder{index: index, fieldNames: c.LogJSONFieldNames}, nil
+	}
+
+	return nil, ErrIllegalHTTPFormat
+}
+
+func (l *httpLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	key, data, err := l.builder.Encode(timestamp, level, fields, msg)
+	if err != nil {
+		return err
+	}
+
+	l.queue.Add(key, data)
+	return nil
+}
+
+func (l *httpLogger) Sync() error {
+	return l.queue.Sync()
+}
+
+// Close sends all buffered messages and stops the background goroutine.
+func (l *httpLogger) Close() error {
+	err := l.queue.Close()
+	l.client.CloseIdleConnections()
+	return err
+}
+
+func (l *httpLogger) send(entries []batchEntry) error {
+	body, contentType, err := l.builder.Build(entries)
+	if err != nil {
+		return err
+	}
+
+	return postHTTP(l.client, l.endpoint, contentType, l.headers, body, l.gzip, ErrHTTPPush)
+}
+
+// postHTTP sends the given body to the endpoint, optionally gzip-compressed. If the
+// response status does not indicate success, an error wrapping statusErr is returned.
+func postHTTP(client *http.Client, endpoint, contentType string, headers map[string]string, body []byte, compress bool, statusErr error) error {
+	if compress {
+		buffer := &bytes.Buffer{}
+		writer := gzip.NewWriter(buffer)
+		if _, err := writer.Write(body); err != nil {
+			return err
+		}
+		if err := writer.Close(); err != nil {
+			return err
+		}
+
+		body = buffer.Bytes()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	if compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: unexpected status %d", statusErr, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// httpDocument returns the JSON encoding of a message as written by the JSON
+// encoding, using the given timestamp format.
+func httpDocument(timestamp time.Time, level LogLevel, fields LogFields, msg string, fieldNames map[string]string, timeFormat timestampFormat) ([]byte, error) {
+	document := fields.clone()
+	document[getField(fieldNames, "message")] = msg
+	document[getField(fieldNames, "timestamp")] = timeFormat.value(timestamp)
+	document[getField(fieldNames, "level")] = level.String()
+
+	return json.Marshal(document)
+}
+
+// jsonArrayPayloadBuilder sends each batch as a JSON array of messages.
+type jsonArrayPayloadBuilder struct {
+	fieldNames map[string]string
+	timeFormat timestampFormat
+}
+
+func (b *jsonArrayPayloadBuilder) Encode(timestamp time.Time, level LogLevel, fields LogFields, msg string) (string, []byte, error) {
+	data, err := httpDocument(timestamp, level, fields, msg, b.fieldNames, b.timeFormat)
+	return "", data, err
+}
+
+func (b *jsonArrayPayloadBuilder) Build(entries []batchEntry) ([]byte, string, error) {
+	documents := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		documents = append(documents, entry.data)
+	}
+
+	body, err := json.Marshal(documents)
+	return body, "application/json", err
+}
+
+// elasticsearchPayloadBuilder sends each batch as a request to the _bulk API which
+// indexes every message into the configured index. The timestamp is written to the
+// @timestamp field unless renamed by LogJSONFieldNames.
+type elasticsearchPayloadBuilder struct {
+	index      string
+	fieldNames map[string]string
+}
+
+func (b *elasticsearchPayloadBuilder) Encode(timestamp time.Time, level LogLevel, fields LogFields, msg string) (string, []byte, error) {
+	fieldNames := map[string]string{"timestamp": "@timestamp"}
+	for key, value := range b.fieldNames {
+		fieldNames[key] = value
+	}
+
+	data, err := httpDocument(timestamp, level, fields, msg, fieldNames, timestampFormat{layout: time.RFC3339Nano})
+	return "", data, err
+}
+
+func (b *elasticsearchPayloadBuilder) Build(entries []batchEntry) ([]byte, string, error) {
+	action, err := json.Marshal(map[string]interface{}{"index": map[string]interface{}{"_index": b.index}})
+	if err != nil {
+		return nil, "", err
+	}
+
+	buffer := &bytes.Buffer{}
+	for _, entry := range entries {
+		buffer.Write(action)
+		buffer.WriteByte('\n')
+		buffer.Write(entry.data)
+		buffer.WriteByte('\n')
+	}
+
+	return buffer.Bytes(), "application/x-ndjson", nil
+}
+
+// lokiPayloadBuilder sends each batch as a request to the Loki push API. The values
+// of the configured label fields are used as stream labels and removed from the log
+// line. Messages are grouped into one stream per distinct label set.
+type lokiPayloadBuilder struct {
+	labels []string
+}
+
+func (b *lokiPayloadBuilder) Encode(timestamp time.Time, level LogLevel, fields LogFields, msg string) (string, []byte, error) {
+	labels := map[string]string{}
+	line := fields.clone()
+
+	for _, name := range b.labels {
+		if value, ok := line[name]; ok {
+			labels[name] = fmt.Sprintf("%v", value)
+			delete(line, name)
+		}
+	}
+
+	line["message"] = msg
+	line["level"] = level.String()
+
+	serializedLine, err := json.Marshal(line)
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Map keys are serialized in sorted order, so equal label sets share a key
+	key, err := json.Marshal(labels)
+	if err != nil {
+		return "", nil, err
+	}
+
+	data, err := json.Marshal([]string{strconv.FormatInt(timestamp.UnixNano(), 10), string(serializedLine)})
+	return string(key), data, err
+}
+
+func (b *lokiPayloadBuilder) Build(entries []batchEntry) ([]byte, string, error) {
+	groups := groupBatchEntries(entries)
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i][0].key < groups[j][0].key })
+
+	streams := make([]interface{}, 0, len(groups))
+	for _, group := range groups {
+		values := make([]json.RawMessage, 0, len(group))
+		for _, entry := range group {
+			values = append(values, entry.data)
+		}
+
+		streams = append(streams, map[string]interface{}{
+			"stream": json.RawMessage(group[0].key),
+			"values": values,
+		})
+	}
+
+	body, err := json.Marshal(map[string]interface{}{"streams": streams})
+	return body, "application/json", err
+}
@@ -0,0 +1,212 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type httpTestServer struct {
+	*httptest.Server
+	mutex    sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func newHTTPTestServer(statuses ...int) *httpTestServer {
+	s := &httpTestServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, body)
+
+		if len(statuses) > 0 {
+			w.WriteHeader(statuses[0])
+			statuses = statuses[1:]
+		}
+	}))
+
+	return s
+}
+
+func (s *httpTestServer) Bodies() [][]byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([][]byte(nil), s.bodies...)
+}
+
+func TestHTTPLoggerJSONArray(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:        "info",
+		LogEncoding:     "http",
+		LogHTTPEndpoint: server.URL,
+		LogHTTPHeaders:  map[string]string{"X-Api-Key": "secret"},
+	})
+	require.Nil(t, err)
+
+	logger.InfoWithFields(LogFields{"attr1": 4321}, "A")
+	logger.Warning("B")
+	require.Nil(t, logger.Sync())
+
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "application/json", server.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, "secret", server.requests[0].Header.Get("X-Api-Key"))
+
+	var documents []LogFields
+	require.Nil(t, json.Unmarshal(server.Bodies()[0], &documents))
+	require.Len(t, documents, 2)
+	assert.Equal(t, "A", documents[0]["message"])
+	assert.Equal(t, "info", documents[0]["level"])
+	assert.Equal(t, float64(4321), documents[0]["attr1"])
+	assert.Equal(t, "B", documents[1]["message"])
+	assert.Equal(t, "warning", documents[1]["level"])
+}
+
+func TestHTTPLoggerLoki(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := newHTTPLogger(&Config{
+		LogHTTPEndpoint: server.URL,
+		LogHTTPFormat:   "loki",
+		LogLokiLabels:   []string{"app", "env"},
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	timestamp := time.Unix(1503939881, 5)
+	require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"app": "api", "env": "prod", "attr1": 1}, "A"))
+	require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"app": "worker"}, "B"))
+	require.Nil(t, logger.Log(timestamp, LevelError, LogFields{"env": "prod", "app": "api"}, "C"))
+	require.Nil(t, logger.Sync())
+
+	assert.JSONEq(t, `{
+		"streams": [
+			{
+				"stream": {"app": "api", "env": "prod"},
+				"values": [
+					["1503939881000000005", "{\"attr1\":1,\"level\":\"info\",\"message\":\"A\"}"],
+					["1503939881000000005", "{\"level\":\"error\",\"message\":\"C\"}"]
+				]
+			},
+			{
+				"stream": {"app": "worker"},
+				"values": [
+					["1503939881000000005", "{\"level\":\"info\",\"message\":\"B\"}"]
+				]
+			}
+		]
+	}`, string(server.Bodies()[0]))
+}
+
+func TestHTTPLoggerElasticsearch(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := newHTTPLogger(&Config{
+		LogHTTPEndpoint:       server.URL + "/_bulk",
+		LogHTTPFormat:         "elasticsearch",
+		LogHTTPGzip:           true,
+		LogElasticsearchIndex: "app-logs",
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC)
+	require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"attr1": 1}, "A"))
+	require.Nil(t, logger.Log(timestamp, LevelDebug, nil, "B"))
+	require.Nil(t, logger.Sync())
+
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "application/x-ndjson", server.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, "gzip", server.requests[0].Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(bytes.NewReader(server.Bodies()[0]))
+	require.Nil(t, err)
+	body, err := io.ReadAll(reader)
+	require.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	require.Len(t, lines, 4)
+	assert.JSONEq(t, `{"index": {"_index": "app-logs"}}`, lines[0])
+	assert.JSONEq(t, `{"@timestamp": "2017-08-28T17:04:41Z", "level": "info", "message": "A", "attr1": 1}`, lines[1])
+	assert.JSONEq(t, `{"index": {"_index": "app-logs"}}`, lines[2])
+	assert.JSONEq(t, `{"@timestamp": "2017-08-28T17:04:41Z", "level": "debug", "message": "B"}`, lines[3])
+}
+
+func TestHTTPLoggerMaxBatchBytes(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	timestamp := time.Now()
+	document, err := httpDocument(timestamp, LevelInfo, LogFields{}, "A", nil, timestampFormat{layout: JSONTimeFormat})
+	require.Nil(t, err)
+
+	// Room for two messages per batch
+	logger, err := newHTTPLogger(&Config{LogHTTPEndpoint: server.URL, LogBatchMaxBytes: len(document) * 5 / 2}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.Nil(t, logger.Log(timestamp, LevelInfo, nil, "A"))
+	}
+	require.Nil(t, logger.Sync())
+
+	bodies := server.Bodies()
+	require.Len(t, bodies, 2)
+
+	var documents []LogFields
+	require.Nil(t, json.Unmarshal(bodies[0], &documents))
+	assert.Len(t, documents, 2)
+	require.Nil(t, json.Unmarshal(bodies[1], &documents))
+	assert.Len(t, documents, 1)
+}
+
+func TestHTTPLoggerRetries(t *testing.T) {
+	server := newHTTPTestServer(http.StatusTooManyRequests, http.StatusBadGateway)
+	defer server.Close()
+
+	logger, err := newHTTPLogger(&Config{LogHTTPEndpoint: server.URL, LogBatchMaxRetries: 2}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, "A"))
+	require.Nil(t, logger.Sync())
+	assert.Len(t, server.Bodies(), 3)
+}
+
+func TestHTTPLoggerClose(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := newHTTPLogger(&Config{LogHTTPEndpoint: server.URL}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, "A"))
+	require.Nil(t, logger.Close())
+	assert.Len(t, server.Bodies(), 1)
+
+	select {
+	case <-logger.queue.stopped:
+	default:
+		t.Fatalf("expected batch queue to be stopped")
+	}
+}
+
+func TestHTTPLoggerIllegalFormat(t *testing.T) {
+	_, err := newHTTPLogger(&Config{LogHTTPFormat: "xml"}, glock.NewRealClock())
+	assert.Equal(t, ErrIllegalHTTPFormat, err)
+}
@@ -2,28 +2,97 @@ package log
 
 import (
 	"fmt"
+	"io"
 	"strings"
 	"text/template"
 
 	"github.com/mgutz/ansi"
 )
 
-func InitLogger(c *Config) (Logger, error) {
-	baseLogger, err := initBaseLogger(c)
+func InitLogger(c *Config, configs ...LoggerConfigFunc) (Logger, error) {
+	options := getLoggerOptions(configs)
+
+	location, err := loadTimezone(c.LogTimezone)
+	if err != nil {
+		return nil, err
+	}
+
+	baseLogger, err := initBaseLogger(c, options)
+	if err != nil {
+		return nil, err
+	}
+
+	format := newTimestampFormat(c.LogTimestampFormat, JSONTimeFormat)
+	timestamps := timestampOptions{
+		useOriginal: c.LogUseOriginalTimestamp,
+		location:    location,
+		format:      &format,
+	}
+
+	if c.LogFormatTimeFields {
+		timestamps.fieldFormat = &format
+	}
+
+	order := fieldOrder{
+		priority:  c.LogFieldPriority,
+		insertion: c.LogFieldOrder == "insertion",
+	}
+
+	return newBaseLogger(baseLogger, parseLogLevel(c.LogLevel), c.LogInitialFields, timestamps, order, options), nil
+}
+
+func initBaseLogger(c *Config, options *loggerOptions) (logSink, error) {
+	switch c.LogEncoding {
+	case "syslog":
+		return newSyslogLogger(c)
+	case "journald":
+		return newJournaldLogger(c)
+	case "gelf":
+		return newGELFLogger(c, options.clock)
+	case "fluent":
+		return newFluentLogger(c, options.clock)
+	case "otlp":
+		return newOTLPLogger(c, options.clock)
+	case "http":
+		return newHTTPLogger(c, options.clock)
+	}
+
+	if c.LogOutputAddress == "" {
+		return initEncodedLogger(c, options.output)
+	}
+
+	dial, err := newStreamDialer(c.LogOutputAddress, c)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := newStreamWriter(dial, c.LogOutputBufferSize, options.clock)
+
+	sink, err := initEncodedLogger(c, writer)
 	if err != nil {
 		return nil, err
 	}
 
-	return newBaseLogger(baseLogger, parseLogLevel(c.LogLevel), c.LogInitialFields), nil
+	return newStreamLogger(sink, writer, options.clock), nil
 }
 
-func initBaseLogger(c *Config) (logSink, error) {
-	if c.LogEncoding == "json" {
-		return newJSONLogger(c.LogJSONFieldNames), nil
+func initEncodedLogger(c *Config, output io.Writer) (logSink, error) {
+	switch c.LogEncoding {
+	case "json":
+		logger := newJSONLogger(c.LogJSONFieldNames, output)
+		logger.profile = jsonProfiles[c.LogJSONProfile]
+		logger.timeFormat = newTimestampFormat(c.LogTimestampFormat, JSONTimeFormat)
+		return logger, nil
+	case "logfmt":
+		logger := newLogfmtLogger(output)
+		logger.timeFormat = newTimestampFormat(c.LogTimestampFormat, JSONTimeFormat)
+		return logger, nil
+	case "msgpack", "cbor":
+		return newBinaryLogger(c.LogEncoding, output), nil
 	}
 
 	tpl, err := newConsoleTemplate(
-		c.LogShortTime,
+		newTimestampFormat(c.LogTimestampFormat, consoleTimeLayout(c.LogShortTime)),
 		c.LogDisplayFields,
 		c.LogDisplayMultilineFields,
 		c.LogFieldBlacklist,
@@ -33,11 +102,11 @@ func initBaseLogger(c *Config) (logSink, error) {
 		return nil, err
 	}
 
-	return newConsoleLogger(tpl, c.LogColorize), nil
+	return newConsoleLogger(tpl, c.LogColorize, output), nil
 }
 
 func newConsoleTemplate(
-	shortTime bool,
+	timeFormat timestampFormat,
 	displayFields bool,
 	displayMultilineFields bool,
 	blacklist []string,
@@ -55,9 +124,9 @@ func newConsoleTemplate(
 	fieldsTemplate := fmt.Sprintf(
 		""+
 			`{{if .fields}}`+
-			`{{range $key, $val := .fields}}`+
-			`{{if shouldDisplayAttr $key}}`+
-			`%s{{$key}}%s=%s{{$val}}`+
+			`{{range .fields}}`+
+			`{{if shouldDisplayAttr .Key}}`+
+			`%s{{.Key}}%s=%s{{.Value}}`+
 			`{{end}}`+
 			`{{end}}`+
 			`%s`+
@@ -68,16 +137,11 @@ func newConsoleTemplate(
 		fieldSuffix,
 	)
 
-	timeFormat := "2006/01/02 15:04:05.000"
-	if shortTime {
-		timeFormat = "15:04:05"
-	}
-
 	text :=
 		"" +
 			`{{color}}` +
 			`[{{uppercase .levelName | printf "%1.1s"}}] ` +
-			fmt.Sprintf(`[{{.timestamp.Format "%s"}}] {{.message}}`, timeFormat) +
+			`[{{formatTimestamp .timestamp}}] {{.message}}` +
 			`{{reset}}`
 
 	if displayFields {
@@ -86,6 +150,7 @@ func newConsoleTemplate(
 
 	colors := map[LogLevel]string{
 		LevelFatal:   ansi.ColorCode("red+b"),
+		LevelPanic:   ansi.ColorCode("magenta+b"),
 		LevelError:   ansi.ColorCode("red"),
 		LevelWarning: ansi.ColorCode("yellow"),
 		LevelInfo:    ansi.ColorCode("green"),
@@ -104,6 +169,7 @@ func newConsoleTemplate(
 			"color":             stringFunc(color),
 			"reset":             stringFunc(reset),
 			"uppercase":         strings.ToUpper,
+			"formatTimestamp":   timeFormat.format,
 			"shouldDisplayAttr": shouldDisplayAttr(blacklist),
 		}
 
@@ -118,6 +184,14 @@ func newConsoleTemplate(
 	return templates, nil
 }
 
+func consoleTimeLayout(shortTime bool) string {
+	if shortTime {
+		return "15:04:05"
+	}
+
+	return "2006/01/02 15:04:05.000"
+}
+
 func stringFunc(value string) func() string {
 	return func() string { return value }
 }
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultJournaldSocket is the path of the socket on which systemd-journald accepts
+// messages using its native protocol.
+const DefaultJournaldSocket = "/run/systemd/journal/socket"
+
+// ErrJournaldUnsupported is returned when the journald encoding is requested on a
+// platform other than Linux.
+var ErrJournaldUnsupported = fmt.Errorf("journald is not supported on this platform")
+
+type journaldLogger struct {
+	conn       *net.UnixConn
+	identifier string
+}
+
+func (l *journaldLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	return l.send(l.encode(level, fields, msg))
+}
+
+func (l *journaldLogger) Close() error {
+	return l.conn.Close()
+}
+
+// encode serializes a message using the journald native protocol. The log fields
+// are written as individual journal fields. The caller field is translated into
+// the well-known CODE_FILE and CODE_LINE fields.
+func (l *journaldLogger) encode(level LogLevel, fields LogFields, msg string) []byte {
+	buffer := &bytes.Buffer{}
+	writeJournaldField(buffer, "MESSAGE", msg)
+	writeJournaldField(buffer, "PRIORITY", strconv.Itoa(syslogSeverity(level)))
+
+	if l.identifier != "" {
+		writeJournaldField(buffer, "SYSLOG_IDENTIFIER", l.identifier)
+	}
+
+	for _, key := range sortedFieldKeys(fields) {
+		if key == "caller" {
+			if file, line, ok := splitCaller(fields[key]); ok {
+				writeJournaldField(buffer, "CODE_FILE", file)
+				writeJournaldField(buffer, "CODE_LINE", strconv.Itoa(line))
+				continue
+			}
+		}
+
+		if name := journaldFieldName(key); name != "" {
+			writeJournaldField(buffer, name, textValue(fields[key]))
+		}
+	}
+
+	return buffer.Bytes()
+}
+
+// journaldReservedFields are the journal fields written by the logger itself.
+var journaldReservedFields = map[string]struct{}{
+	"MESSAGE":           {},
+	"PRIORITY":          {},
+	"SYSLOG_IDENTIFIER": {},
+	"CODE_FILE":         {},
+	"CODE_LINE":         {},
+}
+
+// journaldFieldName converts a log field key into a valid journal field name. Journal
+// field names consist of uppercase letters, digits, and underscores, must not begin
+// with an underscore or a digit, and are at most 64 characters long. Names of fields
+// written by the logger itself are prefixed with FIELD_. An empty string is returned
+// if no valid name can be constructed.
+func journaldFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		if r >= 'a' && r <= 'z' {
+			return r - 'a' + 'A'
+		}
+
+		return '_'
+	}, key)
+
+	name = strings.TrimLeft(name, "_0123456789")
+	if _, ok := journaldReservedFields[name]; ok {
+		name = "FIELD_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return name
+}
+
+// writeJournaldField writes a single field to the given buffer. Values containing
+// a newline are written in the binary form, prefixed with their little-endian
+// 64-bit length.
+func writeJournaldField(buffer *bytes.Buffer, name, value string) {
+	buffer.WriteString(name)
+
+	if !strings.Contains(value, "\n") {
+		buffer.WriteByte('=')
+		buffer.WriteString(value)
+		buffer.WriteByte('\n')
+		return
+	}
+
+	buffer.WriteByte('\n')
+	binary.Write(buffer, binary.LittleEndian, uint64(len(value)))
+	buffer.WriteString(value)
+	buffer.WriteByte('\n')
+}
+
+func journaldIdentifier() string {
+	return filepath.Base(os.Args[0])
+}
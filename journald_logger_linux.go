@@ -0,0 +1,77 @@
+//go:build linux
+
+package log
+
+import (
+	"errors"
+	"net"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+func newJournaldLogger(c *Config) (*journaldLogger, error) {
+	address := c.LogJournaldSocket
+	if address == "" {
+		address = DefaultJournaldSocket
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: address, Net: "unixgram"})
+	if err != nil {
+		return nil, err
+	}
+
+	return &journaldLogger{conn: conn, identifier: journaldIdentifier()}, nil
+}
+
+func (l *journaldLogger) send(data []byte) error {
+	_, err := l.conn.Write(data)
+	if err == nil {
+		return nil
+	}
+
+	// Entries larger than the maximum datagram size are passed to the
+	// journal as a sealed memory file descriptor instead
+	if errors.Is(err, syscall.EMSGSIZE) || errors.Is(err, syscall.ENOBUFS) {
+		return l.sendMemfd(data)
+	}
+
+	return err
+}
+
+func (l *journaldLogger) sendMemfd(data []byte) error {
+	fd, err := unix.MemfdCreate("journal-entry", unix.MFD_CLOEXEC|unix.MFD_ALLOW_SEALING)
+	if err != nil {
+		return err
+	}
+
+	file := os.NewFile(uintptr(fd), "journal-entry")
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+
+	if _, err := unix.FcntlInt(uintptr(fd), unix.F_ADD_SEALS, unix.F_SEAL_SHRINK|unix.F_SEAL_GROW|unix.F_SEAL_WRITE|unix.F_SEAL_SEAL); err != nil {
+		return err
+	}
+
+	// UnixConn.WriteMsgUnix returns net.ErrWriteToConnected on a connected
+	// datagram socket even when the address is nil, so the descriptor is passed
+	// as ancillary data of an empty datagram with a raw sendmsg call
+	rawConn, err := l.conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+	if err := rawConn.Write(func(socket uintptr) bool {
+		sendErr = unix.Sendmsg(int(socket), nil, unix.UnixRights(fd), nil, 0)
+		return sendErr != unix.EAGAIN
+	}); err != nil {
+		return err
+	}
+
+	return sendErr
+}
@@ -0,0 +1,84 @@
+//go:build linux
+
+package log
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+func TestJournaldLoggerLog(t *testing.T) {
+	conn, address := listenJournald(t)
+	defer conn.Close()
+
+	logger, err := newJournaldLogger(&Config{LogJournaldSocket: address})
+	require.Nil(t, err)
+	logger.identifier = "app"
+
+	require.Nil(t, logger.Log(time.Now(), LevelError, LogFields{"attr1": 4321}, "test 1234"))
+
+	buffer := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buffer)
+	require.Nil(t, err)
+	assert.Equal(t, "MESSAGE=test 1234\nPRIORITY=3\nSYSLOG_IDENTIFIER=app\nATTR1=4321\n", string(buffer[:n]))
+}
+
+func TestJournaldLoggerMemfd(t *testing.T) {
+	conn, address := listenJournald(t)
+	defer conn.Close()
+
+	logger, err := newJournaldLogger(&Config{LogJournaldSocket: address})
+	require.Nil(t, err)
+
+	// Larger than the default maximum datagram size
+	message := strings.Repeat("x", 1<<20)
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, message))
+
+	oob := make([]byte, unix.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(make([]byte, 16), oob)
+	require.Nil(t, err)
+	assert.Equal(t, 0, n)
+
+	messages, err := unix.ParseSocketControlMessage(oob[:oobn])
+	require.Nil(t, err)
+	require.Len(t, messages, 1)
+	fds, err := unix.ParseUnixRights(&messages[0])
+	require.Nil(t, err)
+	require.Len(t, fds, 1)
+
+	file := os.NewFile(uintptr(fds[0]), "journal-entry")
+	defer file.Close()
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 1<<30))
+	require.Nil(t, err)
+	assert.True(t, strings.HasPrefix(string(data), "MESSAGE="+message+"\nPRIORITY=6\n"))
+
+	seals, err := unix.FcntlInt(uintptr(fds[0]), unix.F_GET_SEALS, 0)
+	require.Nil(t, err)
+	assert.NotZero(t, seals&unix.F_SEAL_WRITE)
+}
+
+func TestJournaldLoggerMissingSocket(t *testing.T) {
+	_, err := newJournaldLogger(&Config{LogJournaldSocket: filepath.Join(os.TempDir(), "missing-journald-socket")})
+	assert.NotNil(t, err)
+}
+
+func listenJournald(t *testing.T) (*net.UnixConn, string) {
+	dir, err := os.MkdirTemp("", "journald")
+	require.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	address := filepath.Join(dir, "socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: address, Net: "unixgram"})
+	require.Nil(t, err)
+
+	return conn, address
+}
@@ -0,0 +1,11 @@
+//go:build !linux
+
+package log
+
+func newJournaldLogger(c *Config) (*journaldLogger, error) {
+	return nil, ErrJournaldUnsupported
+}
+
+func (l *journaldLogger) send(data []byte) error {
+	return ErrJournaldUnsupported
+}
@@ -0,0 +1,42 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournaldLoggerEncode(t *testing.T) {
+	logger := &journaldLogger{identifier: "app"}
+	data := logger.encode(LevelWarning, LogFields{
+		"caller":    "log/main.go:42",
+		"requestId": "abc",
+		"_private":  1,
+		"multi":     "a\nb",
+		"message":   "shadowed",
+	}, "test 1234")
+
+	expected := "" +
+		"MESSAGE=test 1234\n" +
+		"PRIORITY=4\n" +
+		"SYSLOG_IDENTIFIER=app\n" +
+		"PRIVATE=1\n" +
+		"CODE_FILE=log/main.go\n" +
+		"CODE_LINE=42\n" +
+		"FIELD_MESSAGE=shadowed\n" +
+		"MULTI\n\x03\x00\x00\x00\x00\x00\x00\x00a\nb\n" +
+		"REQUESTID=abc\n"
+
+	assert.Equal(t, expected, string(data))
+}
+
+func TestJournaldFieldName(t *testing.T) {
+	assert.Equal(t, "REQUEST_ID", journaldFieldName("request-id"))
+	assert.Equal(t, "USER_NAME", journaldFieldName("user.name"))
+	assert.Equal(t, "FOO", journaldFieldName("__foo"))
+	assert.Equal(t, "A1", journaldFieldName("1a1"))
+	assert.Equal(t, "", journaldFieldName("_123"))
+	assert.Equal(t, "FIELD_MESSAGE", journaldFieldName("message"))
+	assert.Equal(t, "FIELD_PRIORITY", journaldFieldName("priority"))
+	assert.Len(t, journaldFieldName("A"+string(make([]byte, 80))), 64)
+}
@@ -1,10 +1,10 @@
 package log
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"time"
 )
 
@@ -13,24 +13,32 @@ type jsonLogger struct {
 	messageField   string
 	timestampField string
 	levelField     string
+	profile        jsonProfile
+	timeFormat     timestampFormat
 }
 
 const JSONTimeFormat = "2006-01-02T15:04:05.000-0700"
 
-func newJSONLogger(fieldNames map[string]string) *jsonLogger {
+func newJSONLogger(fieldNames map[string]string, stream io.Writer) *jsonLogger {
 	return &jsonLogger{
-		stream:         os.Stderr,
+		stream:         stream,
 		messageField:   getField(fieldNames, "message"),
 		timestampField: getField(fieldNames, "timestamp"),
 		levelField:     getField(fieldNames, "level"),
+		timeFormat:     timestampFormat{layout: JSONTimeFormat},
 	}
 }
 
 func (l *jsonLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
-	mergedFields := fields.clone()
-	mergedFields[l.messageField] = msg
-	mergedFields[l.timestampField] = timestamp.Format(JSONTimeFormat)
-	mergedFields[l.levelField] = level.String()
+	var mergedFields LogFields
+	if l.profile != nil {
+		mergedFields = l.profile(timestamp, level, fields, msg)
+	} else {
+		mergedFields = fields.clone()
+		mergedFields[l.messageField] = msg
+		mergedFields[l.timestampField] = l.timeFormat.value(timestamp)
+		mergedFields[l.levelField] = level.String()
+	}
 
 	out, err := json.Marshal(mergedFields)
 	if err != nil {
@@ -41,6 +49,64 @@ func (l *jsonLogger) Log(timestamp time.Time, level LogLevel, fields LogFields,
 	return nil
 }
 
+// LogOrdered writes the timestamp, level, and message followed by the fields in the
+// given order. Messages written with a profile are written by Log.
+func (l *jsonLogger) LogOrdered(timestamp time.Time, level LogLevel, fields LogFields, keys []string, msg string) error {
+	if l.profile != nil {
+		return l.Log(timestamp, level, fields, msg)
+	}
+
+	header := map[string]interface{}{
+		l.timestampField: l.timeFormat.value(timestamp),
+		l.levelField:     level.String(),
+		l.messageField:   msg,
+	}
+
+	buffer := bytes.Buffer{}
+	buffer.WriteByte('{')
+
+	writePair := func(key string, value interface{}) error {
+		encodedKey, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+
+		encodedValue, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+
+		buffer.Write(encodedKey)
+		buffer.WriteByte(':')
+		buffer.Write(encodedValue)
+		return nil
+	}
+
+	for _, key := range []string{l.timestampField, l.levelField, l.messageField} {
+		if err := writePair(key, header[key]); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		if _, ok := header[key]; ok {
+			continue
+		}
+
+		if err := writePair(key, fields[key]); err != nil {
+			return err
+		}
+	}
+
+	buffer.WriteString("}\n")
+	fmt.Fprint(l.stream, buffer.String())
+	return nil
+}
+
 func getField(fieldNames map[string]string, field string) string {
 	if value, ok := fieldNames[field]; ok {
 		return value
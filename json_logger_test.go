@@ -10,10 +10,9 @@ import (
 )
 
 func TestJSONLoggerLog(t *testing.T) {
-	logger := newJSONLogger(nil)
 	buffer := bytes.NewBuffer(nil)
+	logger := newJSONLogger(nil, buffer)
 	timestamp := time.Unix(1503939881, 0)
-	logger.stream = buffer
 
 	logger.Log(
 		timestamp,
@@ -33,13 +32,12 @@ func TestJSONLoggerLog(t *testing.T) {
 }
 
 func TestJSONLoggerCustomFieldNames(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
 	logger := newJSONLogger(map[string]string{
 		"timestamp": "@timestamp",
 		"level":     "log_level",
-	})
-	buffer := bytes.NewBuffer(nil)
+	}, buffer)
 	timestamp := time.Unix(1503939881, 0)
-	logger.stream = buffer
 
 	logger.Log(
 		timestamp,
@@ -57,3 +55,77 @@ func TestJSONLoggerCustomFieldNames(t *testing.T) {
 
 	assert.JSONEq(t, expected, string(buffer.Bytes()))
 }
+
+func TestJSONLoggerGCPProfile(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	logger := newJSONLogger(nil, buffer)
+	logger.profile = jsonProfiles["gcp"]
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 123456789, time.UTC)
+
+	logger.Log(timestamp, LevelWarning, LogFields{"attr1": 4321, "caller": "log/main.go:42"}, "test 1234")
+
+	assert.JSONEq(t, `{
+		"severity": "WARNING",
+		"message": "test 1234",
+		"time": "2017-08-28T17:04:41.123456789Z",
+		"logging.googleapis.com/sourceLocation": {"file": "log/main.go", "line": "42"},
+		"attr1": 4321
+	}`, buffer.String())
+}
+
+func TestJSONLoggerECSProfile(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	logger := newJSONLogger(map[string]string{"message": "msg"}, buffer)
+	logger.profile = jsonProfiles["ecs"]
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC)
+
+	logger.Log(timestamp, LevelError, LogFields{
+		"caller":   "log/main.go:42",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	}, "test 1234")
+
+	assert.JSONEq(t, fmt.Sprintf(`{
+		"@timestamp": "2017-08-28T17:04:41Z",
+		"log.level": "error",
+		"message": "test 1234",
+		"ecs.version": "%s",
+		"log.origin.file.name": "log/main.go",
+		"log.origin.file.line": 42,
+		"trace.id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id": "00f067aa0ba902b7"
+	}`, ECSVersion), buffer.String())
+}
+
+func TestJSONLoggerDatadogProfile(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	logger := newJSONLogger(nil, buffer)
+	logger.profile = jsonProfiles["datadog"]
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC)
+
+	logger.Log(timestamp, LevelFatal, LogFields{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	}, "test 1234")
+
+	assert.JSONEq(t, `{
+		"status": "critical",
+		"message": "test 1234",
+		"timestamp": "2017-08-28T17:04:41Z",
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id": "00f067aa0ba902b7",
+		"dd.trace_id": "11803532876627986230",
+		"dd.span_id": "67667974448284343"
+	}`, buffer.String())
+
+	buffer.Reset()
+	logger.Log(timestamp, LevelInfo, LogFields{"dd.trace_id": "1", "trace_id": "bad"}, "test 1234")
+
+	assert.JSONEq(t, `{
+		"status": "info",
+		"message": "test 1234",
+		"timestamp": "2017-08-28T17:04:41Z",
+		"trace_id": "bad",
+		"dd.trace_id": "1"
+	}`, buffer.String())
+}
@@ -0,0 +1,123 @@
+package log
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"time"
+)
+
+// ECSVersion is the version of the Elastic Common Schema emitted by the ecs JSON profile.
+const ECSVersion = "8.11.0"
+
+// jsonProfile builds the document written by the JSON encoding for a message. A
+// profile replaces the default document layout and the field names configured by
+// LogJSONFieldNames.
+type jsonProfile func(timestamp time.Time, level LogLevel, fields LogFields, msg string) LogFields
+
+var jsonProfiles = map[string]jsonProfile{
+	"gcp":     gcpJSONProfile,
+	"ecs":     ecsJSONProfile,
+	"datadog": datadogJSONProfile,
+}
+
+var gcpSeverities = map[LogLevel]string{
+	LevelFatal:   "CRITICAL",
+	LevelPanic:   "CRITICAL",
+	LevelError:   "ERROR",
+	LevelWarning: "WARNING",
+	LevelInfo:    "INFO",
+	LevelDebug:   "DEBUG",
+}
+
+var datadogStatuses = map[LogLevel]string{
+	LevelFatal:   "critical",
+	LevelPanic:   "critical",
+	LevelError:   "error",
+	LevelWarning: "warning",
+	LevelInfo:    "info",
+	LevelDebug:   "debug",
+}
+
+// gcpJSONProfile writes documents understood by the Google Cloud Logging agent. The
+// caller field is moved into the special source location field.
+func gcpJSONProfile(timestamp time.Time, level LogLevel, fields LogFields, msg string) LogFields {
+	document := fields.clone()
+	document["message"] = msg
+	document["time"] = timestamp.Format(time.RFC3339Nano)
+	document["severity"] = gcpSeverities[level]
+
+	if file, line, ok := splitCaller(document["caller"]); ok {
+		delete(document, "caller")
+		document["logging.googleapis.com/sourceLocation"] = map[string]interface{}{
+			"file": file,
+			"line": strconv.Itoa(line),
+		}
+	}
+
+	return document
+}
+
+// ecsJSONProfile writes documents conforming to the Elastic Common Schema. The caller
+// field is split into the log origin fields, and W3C trace context fields are
+// renamed to their ECS equivalents.
+func ecsJSONProfile(timestamp time.Time, level LogLevel, fields LogFields, msg string) LogFields {
+	document := fields.clone()
+	document["message"] = msg
+	document["@timestamp"] = timestamp.Format(time.RFC3339Nano)
+	document["log.level"] = level.String()
+	document["ecs.version"] = ECSVersion
+
+	if file, line, ok := splitCaller(document["caller"]); ok {
+		delete(document, "caller")
+		document["log.origin.file.name"] = file
+		document["log.origin.file.line"] = line
+	}
+
+	for from, to := range map[string]string{"trace_id": "trace.id", "span_id": "span.id"} {
+		if value, ok := document[from]; ok {
+			delete(document, from)
+			document[to] = value
+		}
+	}
+
+	return document
+}
+
+// datadogJSONProfile writes documents understood by the Datadog agent. W3C trace
+// context fields are converted to the decimal Datadog trace and span identifiers
+// unless those are already present.
+func datadogJSONProfile(timestamp time.Time, level LogLevel, fields LogFields, msg string) LogFields {
+	document := fields.clone()
+	document["message"] = msg
+	document["timestamp"] = timestamp.Format(time.RFC3339Nano)
+	document["status"] = datadogStatuses[level]
+
+	for from, to := range map[string]string{"trace_id": "dd.trace_id", "span_id": "dd.span_id"} {
+		if _, ok := document[to]; ok {
+			continue
+		}
+
+		if id, ok := datadogID(document[from]); ok {
+			document[to] = id
+		}
+	}
+
+	return document
+}
+
+// datadogID converts a hex-encoded W3C trace or span id into the decimal form used
+// by Datadog, which consists of the lower 64 bits of the id.
+func datadogID(value interface{}) (string, bool) {
+	s, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+
+	decoded, err := hex.DecodeString(s)
+	if err != nil || (len(decoded) != 8 && len(decoded) != 16) {
+		return "", false
+	}
+
+	return strconv.FormatUint(binary.BigEndian.Uint64(decoded[len(decoded)-8:]), 10), true
+}
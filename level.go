@@ -9,6 +9,11 @@ const (
 	LevelInfo
 	LevelDebug
 	LevelNone
+
+	// LevelPanic is less severe than LevelFatal and more severe than LevelError. It
+	// is defined after the other levels so that their values are unchanged; levels
+	// must be compared with Enabled rather than numerically.
+	LevelPanic
 )
 
 var names = map[LogLevel]string{
@@ -16,6 +21,7 @@ var names = map[LogLevel]string{
 	LevelInfo:    "info",
 	LevelWarning: "warning",
 	LevelError:   "error",
+	LevelPanic:   "panic",
 	LevelFatal:   "fatal",
 }
 
@@ -27,6 +33,24 @@ func (l LogLevel) String() string {
 	return "unknown"
 }
 
+// Enabled returns true if a message at this level is written by a logger whose
+// maximum level is the given level.
+func (l LogLevel) Enabled(max LogLevel) bool {
+	return l.rank() <= max.rank()
+}
+
+// rank orders levels from the most to the least severe.
+func (l LogLevel) rank() int {
+	switch l {
+	case LevelFatal:
+		return 0
+	case LevelPanic:
+		return 1
+	}
+
+	return int(l) + 1
+}
+
 func parseLogLevel(name string) LogLevel {
 	for level, candidate := range names {
 		if candidate == name {
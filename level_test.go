@@ -0,0 +1,26 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelValues(t *testing.T) {
+	assert.Equal(t, LogLevel(0), LevelFatal)
+	assert.Equal(t, LogLevel(1), LevelError)
+	assert.Equal(t, LogLevel(2), LevelWarning)
+	assert.Equal(t, LogLevel(3), LevelInfo)
+	assert.Equal(t, LogLevel(4), LevelDebug)
+	assert.Equal(t, LogLevel(5), LevelNone)
+}
+
+func TestLevelEnabled(t *testing.T) {
+	assert.True(t, LevelFatal.Enabled(LevelFatal))
+	assert.False(t, LevelPanic.Enabled(LevelFatal))
+	assert.True(t, LevelPanic.Enabled(LevelPanic))
+	assert.False(t, LevelError.Enabled(LevelPanic))
+	assert.True(t, LevelPanic.Enabled(LevelError))
+	assert.True(t, LevelError.Enabled(LevelDebug))
+	assert.False(t, LevelDebug.Enabled(LevelInfo))
+}
@@ -0,0 +1,67 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type logfmtLogger struct {
+	stream     io.Writer
+	timeFormat timestampFormat
+}
+
+func newLogfmtLogger(stream io.Writer) *logfmtLogger {
+	return &logfmtLogger{
+		stream:     stream,
+		timeFormat: timestampFormat{layout: JSONTimeFormat},
+	}
+}
+
+// Log writes a single line of space-separated key=value pairs. The timestamp,
+// level, and message are written first, followed by the fields in sorted order.
+func (l *logfmtLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	return l.LogOrdered(timestamp, level, fields, sortedFieldKeys(fields), msg)
+}
+
+// LogOrdered writes a message as Log does, with the fields in the given order.
+func (l *logfmtLogger) LogOrdered(timestamp time.Time, level LogLevel, fields LogFields, keys []string, msg string) error {
+	pairs := []string{
+		"timestamp=" + logfmtValue(l.timeFormat.format(timestamp)),
+		"level=" + logfmtValue(level.String()),
+		"message=" + logfmtValue(msg),
+	}
+
+	for _, key := range keys {
+		pairs = append(pairs, logfmtKey(key)+"="+logfmtValue(textValue(fields[key])))
+	}
+
+	fmt.Fprint(l.stream, strings.Join(pairs, " ")+"\n")
+	return nil
+}
+
+func logfmtKey(key string) string {
+	key = strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, key)
+
+	if key == "" {
+		return "_"
+	}
+
+	return key
+}
+
+func logfmtValue(value string) string {
+	if value == "" || strings.ContainsAny(value, " =\"\\") || strings.IndexFunc(value, func(r rune) bool { return r < ' ' }) >= 0 {
+		return strconv.Quote(value)
+	}
+
+	return value
+}
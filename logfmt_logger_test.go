@@ -0,0 +1,30 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmtLoggerLog(t *testing.T) {
+	buffer := bytes.NewBuffer(nil)
+	logger := newLogfmtLogger(buffer)
+	timestamp := time.Unix(1503939881, 0)
+
+	logger.Log(
+		timestamp,
+		LevelWarning,
+		LogFields{"attr2": "a b", "attr1": 4321, "bad key": `x"y`, "empty": ""},
+		"test 1234",
+	)
+
+	expected := fmt.Sprintf(
+		`timestamp=%s level=warning message="test 1234" attr1=4321 attr2="a b" bad_key="x\"y" empty=""`+"\n",
+		timestamp.Format(JSONTimeFormat),
+	)
+
+	assert.Equal(t, expected, buffer.String())
+}
@@ -1,5 +1,10 @@
 package log
 
+import (
+	"fmt"
+	"io"
+)
+
 type (
 	Logger interface {
 		WithIndirectCaller(frames int) Logger
@@ -19,4 +24,50 @@ type (
 		ErrorWithFields(LogFields, string, ...interface{})
 		FatalWithFields(LogFields, string, ...interface{})
 	}
+
+	// PanicLogger is implemented by loggers that can write a message at LevelPanic
+	// and then panic with the formatted message. Loggers returned by this package
+	// implement it; use the Panic and PanicWithFields functions to panic with any
+	// Logger.
+	PanicLogger interface {
+		Panic(string, ...interface{})
+		PanicWithFields(LogFields, string, ...interface{})
+	}
 )
+
+// Panic writes a message at LevelPanic to the given logger and then panics with
+// the formatted message.
+func Panic(logger Logger, format string, args ...interface{}) {
+	PanicWithFields(logger, nil, format, args...)
+}
+
+// PanicWithFields writes a message with the given fields at LevelPanic to the
+// given logger and then panics with the formatted message. The message is written
+// with LogWithFields if the logger does not implement PanicLogger.
+func PanicWithFields(logger Logger, fields LogFields, format string, args ...interface{}) {
+	logger = logger.WithIndirectCaller(1)
+
+	if panicLogger, ok := logger.(PanicLogger); ok {
+		panicLogger.PanicWithFields(fields, format, args...)
+	} else {
+		logger.LogWithFields(LevelPanic, fields, format, args...)
+	}
+
+	panic(fmt.Sprintf(format, args...))
+}
+
+// Close flushes the given logger and releases the connections and background
+// goroutines of its output. Loggers returned by InitLogger, and loggers wrapping
+// them, implement io.Closer; other loggers are only synced. The logger and every
+// logger derived from it must not be used afterwards.
+func Close(logger Logger) error {
+	return closeLogger(logger)
+}
+
+func closeLogger(logger syncer) error {
+	if closer, ok := logger.(io.Closer); ok {
+		return closer.Close()
+	}
+
+	return logger.Sync()
+}
@@ -0,0 +1,289 @@
+// Package loggrpc provides gRPC interceptors that attach a request-scoped logger
+// to each call and log its completion.
+package loggrpc
+
+import (
+	"context"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/go-nacelle/log/v2"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type (
+	// ConfigFunc is a function used to configure an interceptor.
+	ConfigFunc func(*options)
+
+	options struct {
+		clock           glock.Clock
+		levels          map[codes.Code]log.LogLevel
+		excludedMethods map[string]struct{}
+	}
+)
+
+// DefaultCodeLevels maps each gRPC status code to the level at which completed
+// calls with that code are logged unless configured otherwise.
+var DefaultCodeLevels = map[codes.Code]log.LogLevel{
+	codes.OK:                 log.LevelInfo,
+	codes.Canceled:           log.LevelInfo,
+	codes.InvalidArgument:    log.LevelInfo,
+	codes.NotFound:           log.LevelInfo,
+	codes.AlreadyExists:      log.LevelInfo,
+	codes.Unauthenticated:    log.LevelInfo,
+	codes.DeadlineExceeded:   log.LevelWarning,
+	codes.PermissionDenied:   log.LevelWarning,
+	codes.ResourceExhausted:  log.LevelWarning,
+	codes.FailedPrecondition: log.LevelWarning,
+	codes.Aborted:            log.LevelWarning,
+	codes.OutOfRange:         log.LevelWarning,
+	codes.Unknown:            log.LevelError,
+	codes.Unimplemented:      log.LevelError,
+	codes.Internal:           log.LevelError,
+	codes.Unavailable:        log.LevelError,
+	codes.DataLoss:           log.LevelError,
+}
+
+// WithClock sets the clock used to measure call duration.
+func WithClock(clock glock.Clock) ConfigFunc {
+	return func(o *options) { o.clock = clock }
+}
+
+// WithCodeLevel sets the level at which completed calls with the given status code
+// are logged.
+func WithCodeLevel(code codes.Code, level log.LogLevel) ConfigFunc {
+	return func(o *options) { o.levels[code] = level }
+}
+
+// WithExcludedMethods disables the completion message for calls to the given fully
+// qualified methods (e.g. /grpc.health.v1.Health/Check). Excluded calls still receive
+// a request-scoped logger.
+func WithExcludedMethods(methods ...string) ConfigFunc {
+	return func(o *options) {
+		for _, method := range methods {
+			o.excludedMethods[method] = struct{}{}
+		}
+	}
+}
+
+func getOptions(configs []ConfigFunc) *options {
+	options := &options{
+		clock:           glock.NewRealClock(),
+		levels:          map[codes.Code]log.LogLevel{},
+		excludedMethods: map[string]struct{}{},
+	}
+
+	for code, level := range DefaultCodeLevels {
+		options.levels[code] = level
+	}
+
+	for _, f := range configs {
+		f(options)
+	}
+
+	return options
+}
+
+// UnaryServerInterceptor creates an interceptor that stores a logger derived from
+// the given logger in the context of each unary call and logs the call's completion.
+func UnaryServerInterceptor(logger log.Logger, configs ...ConfigFunc) grpc.UnaryServerInterceptor {
+	options := getOptions(configs)
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		call := options.newCall(ctx, logger, info.FullMethod)
+		resp, err := handler(log.WithLogger(ctx, call.logger), req)
+		call.finish(err, nil)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor creates an interceptor that stores a logger derived from
+// the given logger in the context of each streaming call and logs the call's completion
+// along with the number of messages sent and received.
+func StreamServerInterceptor(logger log.Logger, configs ...ConfigFunc) grpc.StreamServerInterceptor {
+	options := getOptions(configs)
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		call := options.newCall(ss.Context(), logger, info.FullMethod)
+		counter := &messageCounter{}
+		err := handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          log.WithLogger(ss.Context(), call.logger),
+			counter:      counter,
+		})
+		call.finish(err, counter)
+		return err
+	}
+}
+
+// UnaryClientInterceptor creates an interceptor that logs the completion of each
+// outgoing unary call. The derived logger is stored in the context passed to the
+// invoker.
+func UnaryClientInterceptor(logger log.Logger, configs ...ConfigFunc) grpc.UnaryClientInterceptor {
+	options := getOptions(configs)
+
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		call := options.newCall(ctx, logger, method)
+		call.logger = call.logger.WithFields(log.LogFields{"peer": cc.Target()})
+		err := invoker(log.WithLogger(ctx, call.logger), method, req, reply, cc, opts...)
+		call.finish(err, nil)
+		return err
+	}
+}
+
+// StreamClientInterceptor creates an interceptor that logs the completion of each
+// outgoing streaming call along with the number of messages sent and received. A
+// client stream is considered complete once a receive returns an error, including
+// io.EOF, or once the single response of a client-streaming call is received.
+func StreamClientInterceptor(logger log.Logger, configs ...ConfigFunc) grpc.StreamClientInterceptor {
+	options := getOptions(configs)
+
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		call := options.newCall(ctx, logger, method)
+		call.logger = call.logger.WithFields(log.LogFields{"peer": cc.Target()})
+		counter := &messageCounter{}
+
+		cs, err := streamer(log.WithLogger(ctx, call.logger), desc, cc, method, opts...)
+		if err != nil {
+			call.finish(err, counter)
+			return nil, err
+		}
+
+		return &clientStream{ClientStream: cs, desc: desc, call: call, counter: counter}, nil
+	}
+}
+
+//
+// Call
+
+type call struct {
+	options *options
+	logger  log.Logger
+	method  string
+	start   time.Time
+	once    sync.Once
+}
+
+func (o *options) newCall(ctx context.Context, logger log.Logger, method string) *call {
+	fields := log.LogFields{"method": method}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		fields["peer"] = p.Addr.String()
+	}
+
+	return &call{
+		options: o,
+		logger:  logger.WithFields(fields),
+		method:  method,
+		start:   o.clock.Now(),
+	}
+}
+
+func (c *call) finish(err error, counter *messageCounter) {
+	c.once.Do(func() {
+		if _, ok := c.options.excludedMethods[c.method]; ok {
+			return
+		}
+
+		code := status.Code(err)
+		fields := log.LogFields{
+			"code":     code.String(),
+			"duration": c.options.clock.Since(c.start).String(),
+		}
+
+		if err != nil {
+			fields["error"] = err.Error()
+		}
+
+		if counter != nil {
+			fields["messagesSent"] = atomic.LoadInt64(&counter.sent)
+			fields["messagesReceived"] = atomic.LoadInt64(&counter.received)
+		}
+
+		level, ok := c.options.levels[code]
+		if !ok {
+			level = log.LevelError
+		}
+
+		c.logger.LogWithFields(level, fields, "%s completed with code %s", c.method, code)
+	})
+}
+
+//
+// Streams
+
+type messageCounter struct {
+	sent     int64
+	received int64
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	counter *messageCounter
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.counter.sent, 1)
+	}
+
+	return err
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.counter.received, 1)
+	}
+
+	return err
+}
+
+type clientStream struct {
+	grpc.ClientStream
+	desc    *grpc.StreamDesc
+	call    *call
+	counter *messageCounter
+}
+
+func (s *clientStream) SendMsg(m interface{}) error {
+	err := s.ClientStream.SendMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.counter.sent, 1)
+	}
+
+	return err
+}
+
+func (s *clientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		atomic.AddInt64(&s.counter.received, 1)
+
+		// Client-streaming calls complete with their only response
+		if !s.desc.ServerStreams {
+			s.call.finish(nil, s.counter)
+		}
+
+		return nil
+	}
+
+	if err == io.EOF {
+		s.call.finish(nil, s.counter)
+	} else {
+		s.call.finish(err, s.counter)
+	}
+
+	return err
+}
@@ -0,0 +1,188 @@
+package loggrpc
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	testgrpc "google.golang.org/grpc/interop/grpc_testing"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func TestUnaryInterceptors(t *testing.T) {
+	serverLogger := logtest.New()
+	clientLogger := logtest.New()
+	client := newTestClient(t, serverLogger, clientLogger, WithCodeLevel(codes.NotFound, log.LevelWarning))
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.Nil(t, err)
+
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "missing"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	for _, logger := range []*logtest.Logger{serverLogger, clientLogger} {
+		logger.AssertLoggedInOrder(t,
+			logtest.Expect(log.LevelInfo, "/grpc.health.v1.Health/Check completed with code OK", log.LogFields{
+				"method": "/grpc.health.v1.Health/Check",
+				"code":   "OK",
+			}),
+			logtest.Expect(log.LevelWarning, "/grpc.health.v1.Health/Check completed with code NotFound", log.LogFields{
+				"code": "NotFound",
+			}),
+		)
+	}
+
+	messages := serverLogger.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, "bufconn", messages[0].Fields["peer"])
+}
+
+func TestStreamInterceptors(t *testing.T) {
+	serverLogger := logtest.New()
+	clientLogger := logtest.New()
+	client := newTestClient(t, serverLogger, clientLogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.Nil(t, err)
+
+	resp, err := stream.Recv()
+	require.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+	cancel()
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Canceled, status.Code(err))
+
+	clientLogger.AssertLogged(t, log.LevelInfo, "/grpc.health.v1.Health/Watch completed with code Canceled", log.LogFields{
+		"messagesSent":     int64(1),
+		"messagesReceived": int64(1),
+	})
+
+	require.Eventually(t, func() bool { return len(serverLogger.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+	serverLogger.AssertLogged(t, log.LevelInfo, "/grpc.health.v1.Health/Watch completed with code Canceled", log.LogFields{
+		"messagesSent":     int64(1),
+		"messagesReceived": int64(1),
+	})
+}
+
+func TestServerInterceptorStoresLogger(t *testing.T) {
+	logger := logtest.New()
+	interceptor := UnaryServerInterceptor(logger)
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		log.FromContext(ctx).Info("handling")
+		return nil, status.Error(codes.Internal, "oops")
+	})
+	assert.Equal(t, codes.Internal, status.Code(err))
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "handling", log.LogFields{"method": "/test/Method"}),
+		logtest.Expect(log.LevelError, "/test/Method completed with code Internal", log.LogFields{
+			"method": "/test/Method",
+			"error":  "rpc error: code = Internal desc = oops",
+		}),
+	)
+}
+
+func TestExcludedMethods(t *testing.T) {
+	logger := logtest.New()
+	interceptor := UnaryServerInterceptor(logger, WithExcludedMethods("/test/Method"))
+
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	require.Nil(t, err)
+	assert.Empty(t, logger.Messages())
+}
+
+func newTestClient(t *testing.T, serverLogger, clientLogger log.Logger, configs ...ConfigFunc) healthpb.HealthClient {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(UnaryServerInterceptor(serverLogger, configs...)),
+		grpc.StreamInterceptor(StreamServerInterceptor(serverLogger, configs...)),
+	)
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(clientLogger, configs...)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(clientLogger, configs...)),
+	)
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+type streamingInputServer struct {
+	testgrpc.UnimplementedTestServiceServer
+}
+
+func (s *streamingInputServer) StreamingInputCall(stream testgrpc.TestService_StreamingInputCallServer) error {
+	size := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&testgrpc.StreamingInputCallResponse{AggregatedPayloadSize: int32(size)})
+		}
+		if err != nil {
+			return err
+		}
+
+		size += len(req.GetPayload().GetBody())
+	}
+}
+
+func TestClientStreamingInterceptors(t *testing.T) {
+	serverLogger := logtest.New()
+	clientLogger := logtest.New()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(StreamServerInterceptor(serverLogger)))
+	testgrpc.RegisterTestServiceServer(server, &streamingInputServer{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(clientLogger)),
+	)
+	require.Nil(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	stream, err := testgrpc.NewTestServiceClient(conn).StreamingInputCall(context.Background())
+	require.Nil(t, err)
+
+	for _, body := range []string{"foo", "barbaz"} {
+		require.Nil(t, stream.Send(&testgrpc.StreamingInputCallRequest{Payload: &testgrpc.Payload{Body: []byte(body)}}))
+	}
+
+	resp, err := stream.CloseAndRecv()
+	require.Nil(t, err)
+	assert.Equal(t, int32(9), resp.AggregatedPayloadSize)
+
+	clientLogger.AssertLogged(t, log.LevelInfo, "/grpc.testing.TestService/StreamingInputCall completed with code OK", log.LogFields{
+		"messagesSent":     int64(2),
+		"messagesReceived": int64(1),
+	})
+	assert.Len(t, clientLogger.Messages(), 1)
+}
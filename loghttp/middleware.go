@@ -0,0 +1,295 @@
+// Package loghttp provides net/http middleware that attaches a request-scoped
+// logger to each request and logs its completion.
+package loghttp
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/go-nacelle/log/v2"
+)
+
+type (
+	// ConfigFunc is a function used to configure the middleware.
+	ConfigFunc func(*options)
+
+	options struct {
+		clock           glock.Clock
+		levels          map[int]log.LogLevel
+		excludedPaths   map[string]struct{}
+		requestIDHeader string
+	}
+
+	middleware struct {
+		logger  log.Logger
+		next    http.Handler
+		options *options
+	}
+)
+
+// DefaultRequestIDHeader is the request header from which the request ID is read,
+// and the response header to which it is written, unless configured otherwise.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// NewMiddleware creates middleware that derives a request-scoped logger from the
+// given logger for each request. The derived logger is stored in the request context
+// and can be retrieved by handlers via log.FromContext. A message is logged once the
+// wrapped handler completes with the response status, the number of bytes written,
+// and the request duration. Panics in the wrapped handler are recovered, logged, and
+// reported to the client as an internal server error.
+func NewMiddleware(logger log.Logger, configs ...ConfigFunc) func(http.Handler) http.Handler {
+	options := &options{
+		clock: glock.NewRealClock(),
+		levels: map[int]log.LogLevel{
+			1: log.LevelInfo,
+			2: log.LevelInfo,
+			3: log.LevelInfo,
+			4: log.LevelWarning,
+			5: log.LevelError,
+		},
+		excludedPaths:   map[string]struct{}{},
+		requestIDHeader: DefaultRequestIDHeader,
+	}
+
+	for _, f := range configs {
+		f(options)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return &middleware{logger: logger, next: next, options: options}
+	}
+}
+
+// WithClock sets the clock used to measure request duration.
+func WithClock(clock glock.Clock) ConfigFunc {
+	return func(o *options) { o.clock = clock }
+}
+
+// WithStatusClassLevel sets the level at which completed requests are logged for
+// responses in the given status class (e.g. 4 for all 4xx responses). By default,
+// 4xx responses are logged as warnings, 5xx responses are logged as errors, and
+// all other responses are logged as info.
+func WithStatusClassLevel(class int, level log.LogLevel) ConfigFunc {
+	return func(o *options) { o.levels[class] = level }
+}
+
+// WithExcludedPaths disables the completion message for requests whose URL path
+// exactly matches one of the given paths. Excluded requests still receive a
+// request-scoped logger.
+func WithExcludedPaths(paths ...string) ConfigFunc {
+	return func(o *options) {
+		for _, path := range paths {
+			o.excludedPaths[path] = struct{}{}
+		}
+	}
+}
+
+// WithRequestIDHeader sets the header from which the request ID is read.
+func WithRequestIDHeader(name string) ConfigFunc {
+	return func(o *options) { o.requestIDHeader = name }
+}
+
+func (m *middleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := m.options.clock.Now()
+
+	requestID := r.Header.Get(m.options.requestIDHeader)
+	if !isValidRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	w.Header().Set(m.options.requestIDHeader, requestID)
+
+	logger := m.logger.WithFields(log.LogFields{
+		"method":     r.Method,
+		"path":       r.URL.Path,
+		"remoteAddr": r.RemoteAddr,
+		"requestID":  requestID,
+		"userAgent":  r.UserAgent(),
+	})
+
+	rw := &responseWriter{ResponseWriter: w}
+	r = r.WithContext(log.WithLogger(r.Context(), logger))
+
+	defer func() {
+		fields := log.LogFields{}
+		level := m.levelFor(rw.statusCode())
+
+		if err := recover(); err != nil {
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			if !rw.wroteHeader {
+				rw.WriteHeader(http.StatusInternalServerError)
+			}
+
+			// Recovered panics are logged as errors even if the handler
+			// had already written a successful status
+			level = log.LevelError
+			fields["panic"] = fmt.Sprintf("%v", err)
+			fields["stack"] = string(debug.Stack())
+		}
+
+		if _, ok := m.options.excludedPaths[r.URL.Path]; ok && fields["panic"] == nil {
+			return
+		}
+
+		fields["status"] = rw.statusCode()
+		fields["bytesWritten"] = rw.bytesWritten
+		fields["duration"] = m.options.clock.Since(start).String()
+
+		logger.LogWithFields(
+			level,
+			fields,
+			"%s %s completed with status %d",
+			r.Method,
+			r.URL.Path,
+			rw.statusCode(),
+		)
+	}()
+
+	m.next.ServeHTTP(rw, r)
+}
+
+func (m *middleware) levelFor(status int) log.LogLevel {
+	if level, ok := m.options.levels[status/100]; ok {
+		return level
+	}
+
+	return log.LevelInfo
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+
+	return hex.EncodeToString(buf)
+}
+
+// maxRequestIDLength is the maximum length of a request ID supplied by the client.
+const maxRequestIDLength = 128
+
+// isValidRequestID reports whether a request ID supplied by the client may be
+// echoed into the response and the logs. Valid IDs are non-empty, at most
+// maxRequestIDLength bytes long, and consist of ASCII letters, digits, and the
+// characters '-', '_', '.', and ':'.
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		switch c := requestID[i]; {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+//
+// Response Writer
+
+type responseWriter struct {
+	http.ResponseWriter
+	status       int
+	wroteHeader  bool
+	hijacked     bool
+	bytesWritten int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytesWritten += n
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack takes over the underlying connection. Requests whose connection is hijacked
+// before a header is written are logged with status 101.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, http.ErrNotSupported
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err == nil {
+		w.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+// ReadFrom copies the reader into the response, using the underlying writer's
+// io.ReaderFrom implementation if available.
+func (w *responseWriter) ReadFrom(r io.Reader) (int64, error) {
+	readerFrom, ok := w.ResponseWriter.(io.ReaderFrom)
+	if !ok {
+		// Hide this method from io.Copy to avoid recursion
+		return io.Copy(struct{ io.Writer }{w}, r)
+	}
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	n, err := readerFrom.ReadFrom(r)
+	w.bytesWritten += int(n)
+	return n, err
+}
+
+func (w *responseWriter) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := w.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+
+	return http.ErrNotSupported
+}
+
+// Unwrap returns the underlying response writer for use by http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) statusCode() int {
+	if !w.wroteHeader {
+		if w.hijacked {
+			return http.StatusSwitchingProtocols
+		}
+
+		return http.StatusOK
+	}
+
+	return w.status
+}
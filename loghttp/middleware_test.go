@@ -0,0 +1,207 @@
+package loghttp
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	logger := logtest.New()
+	clock := glock.NewMockClock()
+
+	handler := NewMiddleware(logger, WithClock(clock))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		log.FromContext(r.Context()).Info("handling")
+		clock.Advance(time.Second)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("hello"))
+	}))
+
+	r := httptest.NewRequest("POST", "/users", nil)
+	r.Header.Set("X-Request-ID", "abc")
+	r.Header.Set("User-Agent", "test-agent")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "abc", w.Header().Get("X-Request-ID"))
+
+	requestFields := log.LogFields{
+		"method":     "POST",
+		"path":       "/users",
+		"remoteAddr": "192.0.2.1:1234",
+		"requestID":  "abc",
+		"userAgent":  "test-agent",
+	}
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "handling", requestFields),
+		logtest.Expect(log.LevelInfo, "POST /users completed with status 201", requestFields, log.LogFields{
+			"status":       201,
+			"bytesWritten": 5,
+			"duration":     "1s",
+		}),
+	)
+}
+
+func TestMiddlewareGeneratesRequestID(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	handler.ServeHTTP(w, r)
+
+	requestID := w.Header().Get("X-Request-ID")
+	assert.Len(t, requestID, 32)
+	assert.Empty(t, r.Header.Get("X-Request-ID"))
+
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, requestID, messages[0].Fields["requestID"])
+	assert.Equal(t, 200, messages[0].Fields["status"])
+}
+
+func TestMiddlewareStatusClassLevels(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger, WithStatusClassLevel(4, log.LevelDebug))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+
+	for _, path := range []string{"/", "/missing", "/broken"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "GET / completed with status 200"),
+		logtest.Expect(log.LevelDebug, "GET /missing completed with status 404"),
+		logtest.Expect(log.LevelError, "GET /broken completed with status 502"),
+	)
+}
+
+func TestMiddlewareExcludedPaths(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger, WithExcludedPaths("/healthz"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+	assert.Empty(t, logger.Messages())
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz/deep", nil))
+	assert.Len(t, logger.Messages(), 1)
+}
+
+func TestMiddlewareRecoversPanics(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, log.LevelError, messages[0].Level)
+	assert.Equal(t, "oops", messages[0].Fields["panic"])
+	assert.Contains(t, messages[0].Fields["stack"], "loghttp.TestMiddlewareRecoversPanics")
+}
+
+func TestMiddlewareRecoversPanicsAfterWriteHeader(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		panic("oops")
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	logger.AssertLogged(t, log.LevelError, "GET / completed with status 200", log.LogFields{"panic": "oops"})
+}
+
+func TestMiddlewareRejectsInvalidRequestIDs(t *testing.T) {
+	logger := logtest.New()
+	handler := NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, requestID := range []string{"abc\x1b[31m", "a b", strings.Repeat("a", 129)} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("X-Request-ID", requestID)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+
+		assert.Len(t, w.Header().Get("X-Request-ID"), 32)
+	}
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Request-ID", "req-1.a_b:c")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "req-1.a_b:c", w.Header().Get("X-Request-ID"))
+}
+
+func TestMiddlewareHijack(t *testing.T) {
+	logger := logtest.New()
+	server := httptest.NewServer(NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		require.Nil(t, err)
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: test\r\nConnection: Upgrade\r\n\r\n")
+		rw.Flush()
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusSwitchingProtocols, resp.StatusCode)
+
+	require.Eventually(t, func() bool { return len(logger.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+	logger.AssertLogged(t, log.LevelInfo, "GET / completed with status 101")
+}
+
+func TestMiddlewareReadFrom(t *testing.T) {
+	logger := logtest.New()
+	server := httptest.NewServer(NewMiddleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, ok := w.(io.ReaderFrom)
+		require.True(t, ok)
+
+		io.Copy(w, strings.NewReader("hello world"))
+	})))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	require.Nil(t, err)
+	body, err := io.ReadAll(bufio.NewReader(resp.Body))
+	resp.Body.Close()
+	require.Nil(t, err)
+	assert.Equal(t, "hello world", string(body))
+
+	require.Eventually(t, func() bool { return len(logger.Messages()) == 1 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 11, logger.Messages()[0].Fields["bytesWritten"])
+}
+
+func TestMiddlewarePushNotSupported(t *testing.T) {
+	handler := NewMiddleware(logtest.New())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.ErrNotSupported, w.(http.Pusher).Push("/style.css", nil))
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
@@ -0,0 +1,118 @@
+// Package logotel correlates log messages with OpenTelemetry traces by adding the
+// active trace and span identifiers from a context as log fields.
+package logotel
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+
+	"github.com/go-nacelle/log/v2"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type (
+	// Convention converts a valid span context into the log fields expected by a
+	// particular log ingestion backend.
+	Convention func(sc trace.SpanContext) log.LogFields
+
+	// ConfigFunc is a function used to configure trace field extraction.
+	ConfigFunc func(*options)
+
+	options struct {
+		conventions []Convention
+	}
+)
+
+// W3C emits the trace ID, span ID, and trace flags as lowercase hex strings using
+// the field names trace_id, span_id, and trace_flags.
+var W3C = FieldNames("trace_id", "span_id", "trace_flags")
+
+// Datadog emits the lower 64 bits of the trace ID and the span ID as decimal strings
+// using the field names dd.trace_id and dd.span_id.
+func Datadog(sc trace.SpanContext) log.LogFields {
+	traceID := sc.TraceID()
+	spanID := sc.SpanID()
+
+	return log.LogFields{
+		"dd.trace_id": strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10),
+		"dd.span_id":  strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10),
+	}
+}
+
+// GCP emits the trace resource name, span ID, and sampling decision using the
+// special fields recognized by Google Cloud Logging.
+func GCP(projectID string) Convention {
+	return func(sc trace.SpanContext) log.LogFields {
+		return log.LogFields{
+			"logging.googleapis.com/trace":         fmt.Sprintf("projects/%s/traces/%s", projectID, sc.TraceID()),
+			"logging.googleapis.com/spanId":        sc.SpanID().String(),
+			"logging.googleapis.com/trace_sampled": sc.IsSampled(),
+		}
+	}
+}
+
+// FieldNames emits the trace ID, span ID, and trace flags as lowercase hex strings
+// using the given field names. Empty names are omitted.
+func FieldNames(traceID, spanID, traceFlags string) Convention {
+	return func(sc trace.SpanContext) log.LogFields {
+		fields := log.LogFields{}
+		if traceID != "" {
+			fields[traceID] = sc.TraceID().String()
+		}
+		if spanID != "" {
+			fields[spanID] = sc.SpanID().String()
+		}
+		if traceFlags != "" {
+			fields[traceFlags] = sc.TraceFlags().String()
+		}
+
+		return fields
+	}
+}
+
+// WithConvention adds a convention used to name trace fields. If no convention is
+// supplied, W3C is used. Supplying multiple conventions emits the fields of each.
+func WithConvention(convention Convention) ConfigFunc {
+	return func(o *options) { o.conventions = append(o.conventions, convention) }
+}
+
+// Fields returns the log fields describing the span active in the given context.
+// If the context does not carry a valid span context, no fields are returned.
+func Fields(ctx context.Context, configs ...ConfigFunc) log.LogFields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	options := &options{}
+	for _, f := range configs {
+		f(options)
+	}
+
+	if len(options.conventions) == 0 {
+		options.conventions = []Convention{W3C}
+	}
+
+	fields := log.LogFields{}
+	for _, convention := range options.conventions {
+		for k, v := range convention(sc) {
+			fields[k] = v
+		}
+	}
+
+	return fields
+}
+
+// WithContext returns a logger derived from the given logger that decorates each
+// message with the fields describing the span active in the given context.
+func WithContext(ctx context.Context, logger log.Logger, configs ...ConfigFunc) log.Logger {
+	return logger.WithFields(Fields(ctx, configs...))
+}
+
+// FromContext returns the logger stored in the given context via log.WithLogger
+// decorated with the fields describing the span active in the same context.
+func FromContext(ctx context.Context, configs ...ConfigFunc) log.Logger {
+	return WithContext(ctx, log.FromContext(ctx), configs...)
+}
@@ -0,0 +1,71 @@
+package logotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testTraceID = trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36}
+	testSpanID  = trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7}
+)
+
+func testContext() context.Context {
+	return trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    testTraceID,
+		SpanID:     testSpanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+}
+
+func TestFieldsW3C(t *testing.T) {
+	assert.Equal(t, log.LogFields{
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":     "00f067aa0ba902b7",
+		"trace_flags": "01",
+	}, Fields(testContext()))
+}
+
+func TestFieldsDatadog(t *testing.T) {
+	assert.Equal(t, log.LogFields{
+		"dd.trace_id": "11803532876627986230",
+		"dd.span_id":  "67667974448284343",
+	}, Fields(testContext(), WithConvention(Datadog)))
+}
+
+func TestFieldsGCP(t *testing.T) {
+	assert.Equal(t, log.LogFields{
+		"logging.googleapis.com/trace":         "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736",
+		"logging.googleapis.com/spanId":        "00f067aa0ba902b7",
+		"logging.googleapis.com/trace_sampled": true,
+	}, Fields(testContext(), WithConvention(GCP("my-project"))))
+}
+
+func TestFieldsMultipleConventions(t *testing.T) {
+	fields := Fields(testContext(), WithConvention(FieldNames("traceId", "", "")), WithConvention(Datadog))
+	assert.Equal(t, log.LogFields{
+		"traceId":     "4bf92f3577b34da6a3ce929d0e0e4736",
+		"dd.trace_id": "11803532876627986230",
+		"dd.span_id":  "67667974448284343",
+	}, fields)
+}
+
+func TestFieldsWithoutSpan(t *testing.T) {
+	assert.Nil(t, Fields(context.Background()))
+}
+
+func TestFromContext(t *testing.T) {
+	logger := logtest.New()
+	ctx := log.WithLogger(testContext(), logger)
+
+	FromContext(ctx).Info("foo")
+	logger.AssertLogged(t, log.LevelInfo, "foo", log.LogFields{
+		"trace_id": "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":  "00f067aa0ba902b7",
+	})
+}
@@ -0,0 +1,7 @@
+package logradapter
+
+import "github.com/go-logr/logr"
+
+func helper(logger logr.Logger) {
+	logger.Info("helper")
+}
@@ -0,0 +1,142 @@
+// Package logradapter implements a go-logr LogSink on top of a nacelle Logger so
+// that libraries accepting a logr.Logger share the same output format and level
+// controls as the rest of the application.
+package logradapter
+
+import (
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"github.com/go-nacelle/log/v2"
+)
+
+// FieldLogger is a field assigned to each message logged through a logger that
+// has been given a name. Its value is the dot-separated list of names.
+const FieldLogger = "logger"
+
+type (
+	// ConfigFunc is a function used to configure a LogSink.
+	ConfigFunc func(*options)
+
+	options struct {
+		verbosity int
+	}
+
+	sink struct {
+		logger    log.Logger
+		name      string
+		depth     int
+		verbosity int
+	}
+)
+
+var (
+	_ logr.LogSink          = &sink{}
+	_ logr.CallDepthLogSink = &sink{}
+)
+
+// New creates a logr.Logger backed by the given logger.
+func New(logger log.Logger, configs ...ConfigFunc) logr.Logger {
+	return logr.New(NewLogSink(logger, configs...))
+}
+
+// NewLogSink creates a logr.LogSink backed by the given logger. A MinimalLogger
+// can be used by first wrapping it with log.FromMinimalLogger. Messages logged
+// at V-level 0 are logged at the info level, and messages logged at any higher
+// V-level are logged at the debug level.
+func NewLogSink(logger log.Logger, configs ...ConfigFunc) logr.LogSink {
+	options := &options{verbosity: -1}
+	for _, f := range configs {
+		f(options)
+	}
+
+	return &sink{logger: logger, verbosity: options.verbosity}
+}
+
+// WithVerbosity sets the highest V-level reported as enabled. By default, all
+// V-levels are enabled and filtering is left to the level of the wrapped logger.
+func WithVerbosity(verbosity int) ConfigFunc {
+	return func(o *options) { o.verbosity = verbosity }
+}
+
+func (s *sink) Init(info logr.RuntimeInfo) {
+	// The sink's exported and internal log methods add two frames on top of
+	// those added by logr
+	s.depth += info.CallDepth + 2
+}
+
+func (s *sink) Enabled(level int) bool {
+	return s.verbosity < 0 || level <= s.verbosity
+}
+
+func (s *sink) Info(level int, msg string, keysAndValues ...interface{}) {
+	logLevel := log.LevelInfo
+	if level > 0 {
+		logLevel = log.LevelDebug
+	}
+
+	s.log(logLevel, toFields(keysAndValues), msg)
+}
+
+func (s *sink) Error(err error, msg string, keysAndValues ...interface{}) {
+	fields := toFields(keysAndValues)
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	s.log(log.LevelError, fields, msg)
+}
+
+func (s *sink) WithValues(keysAndValues ...interface{}) logr.LogSink {
+	clone := *s
+	clone.logger = s.logger.WithFields(toFields(keysAndValues))
+	return &clone
+}
+
+func (s *sink) WithName(name string) logr.LogSink {
+	clone := *s
+	if s.name == "" {
+		clone.name = name
+	} else {
+		clone.name = s.name + "." + name
+	}
+
+	return &clone
+}
+
+func (s *sink) WithCallDepth(depth int) logr.LogSink {
+	clone := *s
+	clone.depth += depth
+	return &clone
+}
+
+func (s *sink) log(level log.LogLevel, fields log.LogFields, msg string) {
+	if s.name != "" {
+		fields[FieldLogger] = s.name
+	}
+
+	logger := s.logger
+	if s.depth > 0 {
+		logger = logger.WithIndirectCaller(s.depth)
+	}
+
+	logger.LogWithFields(level, fields, "%s", msg)
+}
+
+func toFields(keysAndValues []interface{}) log.LogFields {
+	fields := log.LogFields{}
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprintf("%v", keysAndValues[i])
+		}
+
+		if i+1 < len(keysAndValues) {
+			fields[key] = keysAndValues[i+1]
+		} else {
+			fields[key] = "<no-value>"
+		}
+	}
+
+	return fields
+}
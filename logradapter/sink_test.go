@@ -0,0 +1,72 @@
+package logradapter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogSink(t *testing.T) {
+	logger := logtest.New()
+	logrLogger := New(logger)
+
+	logrLogger.Info("foo", "a", 1, "b", "two")
+	logrLogger.V(1).Info("bar")
+	logrLogger.V(4).Info("baz")
+	logrLogger.Error(errors.New("oops"), "bonk", "c", true)
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "foo", log.LogFields{"a": 1, "b": "two"}),
+		logtest.Expect(log.LevelDebug, "bar"),
+		logtest.Expect(log.LevelDebug, "baz"),
+		logtest.Expect(log.LevelError, "bonk", log.LogFields{"c": true, "error": "oops"}),
+	)
+}
+
+func TestLogSinkWithValuesAndName(t *testing.T) {
+	logger := logtest.New()
+	logrLogger := New(logger).WithName("controller").WithValues("x", "y").WithName("reconciler")
+
+	logrLogger.Info("foo", "odd")
+
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, log.LogFields{
+		"logger": "controller.reconciler",
+		"x":      "y",
+		"odd":    "<no-value>",
+	}, messages[0].Fields)
+}
+
+func TestLogSinkVerbosity(t *testing.T) {
+	logger := logtest.New()
+	logrLogger := New(logger, WithVerbosity(1))
+
+	logrLogger.V(1).Info("foo")
+	logrLogger.V(2).Info("bar")
+
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, "foo", messages[0].Message)
+}
+
+func TestLogSinkCaller(t *testing.T) {
+	logger := logtest.New()
+	logrLogger := New(logger)
+
+	logrLogger.Info("foo")
+	helper(logrLogger.WithCallDepth(1))
+
+	messages := logger.Messages()
+	require.Len(t, messages, 2)
+
+	// Note: these values refer to the line numbers containing the calls to `Info`
+	// and `helper` in the test setup above. If code is added before those lines,
+	// these values must be updated.
+	assert.Equal(t, "logradapter/sink_test.go:61", messages[0].Caller)
+	assert.Equal(t, "logradapter/sink_test.go:62", messages[1].Caller)
+}
@@ -0,0 +1,19 @@
+module github.com/go-nacelle/log/v2/logrusadapter
+
+go 1.21
+
+require (
+	github.com/go-nacelle/log/v2 v2.1.0
+	github.com/sirupsen/logrus v1.9.3
+	github.com/stretchr/testify v1.12.1
+)
+
+require (
+	github.com/derision-test/glock v0.0.0-20210316032053-f5b74334bb29 // indirect
+	github.com/mattn/go-colorable v0.1.14 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/mgutz/ansi v0.0.0-20200706080929-d51e80ef957d // indirect
+	go.yaml.in/yaml/v3 v3.0.5 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	google.golang.org/protobuf v1.36.5 // indirect
+)
@@ -0,0 +1,70 @@
+package logrusadapter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type hook struct {
+	logger log.Logger
+}
+
+var _ logrus.Hook = &hook{}
+
+// NewHook creates a logrus hook that forwards each entry to the given logger.
+// To avoid writing each entry twice, set the output of the logrus logger to
+// io.Discard. Panic and fatal-level entries are logged at the error level, and
+// logrus remains responsible for panicking or exiting afterwards.
+func NewHook(logger log.Logger) logrus.Hook {
+	return &hook{logger: logger}
+}
+
+func (h *hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *hook) Fire(entry *logrus.Entry) error {
+	fields := make(log.LogFields, len(entry.Data)+1)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		fields[key] = value
+	}
+
+	if entry.Caller != nil {
+		fields["caller"] = fmt.Sprintf("%s:%d", trimPath(entry.Caller.File), entry.Caller.Line)
+	}
+
+	h.logger.LogWithFields(fromLogrusLevel(entry.Level), fields, "%s", entry.Message)
+	return nil
+}
+
+// trimPath returns the last two elements of the given path, matching the caller
+// field written by the log package.
+func trimPath(path string) string {
+	if idx := strings.LastIndexByte(path, '/'); idx >= 0 {
+		if idx := strings.LastIndexByte(path[:idx], '/'); idx >= 0 {
+			return path[idx+1:]
+		}
+	}
+
+	return path
+}
+
+func fromLogrusLevel(level logrus.Level) log.LogLevel {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return log.LevelDebug
+	case logrus.InfoLevel:
+		return log.LevelInfo
+	case logrus.WarnLevel:
+		return log.LevelWarning
+	}
+
+	return log.LevelError
+}
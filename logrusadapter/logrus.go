@@ -0,0 +1,65 @@
+// Package logrusadapter converts between logrus loggers and nacelle loggers,
+// allowing services to migrate between the two incrementally.
+package logrusadapter
+
+import (
+	"github.com/go-nacelle/log/v2"
+	"github.com/sirupsen/logrus"
+)
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+var _ log.MinimalLogger = &logrusLogger{}
+
+// FromLogrus wraps the given logrus logger as a MinimalLogger. Messages logged at
+// the panic and fatal levels are written with logrus's Panic and Fatal methods, which
+// panic or terminate the process via the logrus logger's exit function.
+func FromLogrus(logger *logrus.Logger) log.MinimalLogger {
+	return &logrusLogger{entry: logrus.NewEntry(logger)}
+}
+
+func (l *logrusLogger) WithFields(fields log.LogFields) log.MinimalLogger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	return &logrusLogger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+func (l *logrusLogger) LogWithFields(level log.LogLevel, fields log.LogFields, format string, args ...interface{}) {
+	entry := l.entry.WithFields(logrus.Fields(fields))
+
+	switch level {
+	case log.LevelFatal:
+		entry.Fatalf(format, args...)
+	case log.LevelPanic:
+		entry.Panicf(format, args...)
+	default:
+		entry.Logf(toLogrusLevel(level), format, args...)
+	}
+}
+
+func (l *logrusLogger) Sync() error {
+	return nil
+}
+
+func toLogrusLevel(level log.LogLevel) logrus.Level {
+	switch level {
+	case log.LevelDebug:
+		return logrus.DebugLevel
+	case log.LevelInfo:
+		return logrus.InfoLevel
+	case log.LevelWarning:
+		return logrus.WarnLevel
+	case log.LevelError:
+		return logrus.ErrorLevel
+	case log.LevelPanic:
+		return logrus.PanicLevel
+	case log.LevelFatal:
+		return logrus.FatalLevel
+	}
+
+	return logrus.InfoLevel
+}
@@ -0,0 +1,89 @@
+package logrusadapter
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromLogrus(t *testing.T) {
+	logrusLogger, hook := test.NewNullLogger()
+	logrusLogger.SetLevel(logrus.InfoLevel)
+	exited := false
+	logrusLogger.ExitFunc = func(int) { exited = true }
+	logger := log.FromMinimalLogger(FromLogrus(logrusLogger))
+
+	logger.WithFields(log.LogFields{"a": 1}).InfoWithFields(log.LogFields{"b": "two"}, "foo %d", 12)
+	logger.Debug("filtered")
+	logger.Warning("bar")
+	logger.Fatal("baz")
+
+	entries := hook.AllEntries()
+	require.Len(t, entries, 3)
+	assert.Equal(t, logrus.InfoLevel, entries[0].Level)
+	assert.Equal(t, "foo 12", entries[0].Message)
+	assert.Equal(t, 1, entries[0].Data["a"])
+	assert.Equal(t, "two", entries[0].Data["b"])
+	// Note: this value refers to the line number containing the first log call in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "logrusadapter/logrus_test.go:26", entries[0].Data["caller"])
+	assert.Equal(t, logrus.WarnLevel, entries[1].Level)
+	assert.Equal(t, logrus.FatalLevel, entries[2].Level)
+	assert.True(t, exited)
+}
+
+func TestNewHook(t *testing.T) {
+	logger := logtest.New()
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(io.Discard)
+	logrusLogger.SetLevel(logrus.TraceLevel)
+	logrusLogger.AddHook(NewHook(logger))
+
+	logrusLogger.WithField("a", 1).WithError(errors.New("oops")).Info("foo")
+	logrusLogger.Trace("bar")
+	logrusLogger.Warn("baz")
+	logrusLogger.Error("bonk")
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "foo", log.LogFields{"a": 1, "error": "oops"}),
+		logtest.Expect(log.LevelDebug, "bar"),
+		logtest.Expect(log.LevelWarning, "baz"),
+		logtest.Expect(log.LevelError, "bonk"),
+	)
+}
+
+func TestNewHookCaller(t *testing.T) {
+	logger := logtest.New()
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(io.Discard)
+	logrusLogger.SetReportCaller(true)
+	logrusLogger.AddHook(NewHook(logger))
+
+	_, file, line, _ := runtime.Caller(0)
+	logrusLogger.Info("foo")
+
+	messages := logger.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, fmt.Sprintf("logrusadapter/%s:%d", filepath.Base(file), line+1), messages[0].Caller)
+}
+
+func TestNewHookPanic(t *testing.T) {
+	logger := logtest.New()
+	logrusLogger := logrus.New()
+	logrusLogger.SetOutput(io.Discard)
+	logrusLogger.AddHook(NewHook(logger))
+
+	assert.Panics(t, func() { logrusLogger.Panic("boom") })
+	logger.AssertLogged(t, log.LevelError, "boom")
+}
@@ -0,0 +1,130 @@
+package logtest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+)
+
+// Expectation describes a message expected to be captured by a Logger.
+type Expectation struct {
+	Level     log.LogLevel
+	Substring string
+	Fields    log.LogFields
+}
+
+// Expect creates an Expectation matching messages logged at the given level
+// whose rendered text contains the given substring and whose fields include
+// each of the given fields.
+func Expect(level log.LogLevel, substring string, fields ...log.LogFields) Expectation {
+	return Expectation{Level: level, Substring: substring, Fields: merge(fields...)}
+}
+
+// Matches returns true if the given message satisfies the expectation.
+func (e Expectation) Matches(message Message) bool {
+	if message.Level != e.Level || !strings.Contains(message.Message, e.Substring) {
+		return false
+	}
+
+	for key, expected := range e.Fields {
+		actual, ok := message.Fields[key]
+		if !ok || !reflect.DeepEqual(expected, actual) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String describes the expectation.
+func (e Expectation) String() string {
+	description := fmt.Sprintf("[%s] containing %q", strings.ToUpper(e.Level.String()), e.Substring)
+	for _, key := range sortedKeys(e.Fields) {
+		description += fmt.Sprintf(" %s=%v", key, e.Fields[key])
+	}
+
+	return description
+}
+
+// AssertLogged fails the test if no captured message matches the given level,
+// substring, and fields.
+func (l *Logger) AssertLogged(t testing.TB, level log.LogLevel, substring string, fields ...log.LogFields) bool {
+	t.Helper()
+
+	expectation := Expect(level, substring, fields...)
+	for _, message := range l.Messages() {
+		if expectation.Matches(message) {
+			return true
+		}
+	}
+
+	t.Errorf("expected a message matching %s\n%s", expectation, l.describe())
+	return false
+}
+
+// AssertNotLogged fails the test if any captured message matches the given level,
+// substring, and fields.
+func (l *Logger) AssertNotLogged(t testing.TB, level log.LogLevel, substring string, fields ...log.LogFields) bool {
+	t.Helper()
+
+	expectation := Expect(level, substring, fields...)
+	for _, message := range l.Messages() {
+		if expectation.Matches(message) {
+			t.Errorf("expected no message matching %s\nfound: %s", expectation, message)
+			return false
+		}
+	}
+
+	return true
+}
+
+// AssertLoggedInOrder fails the test unless the captured messages contain a
+// match for each expectation in the given order. Unmatched messages may occur
+// between matches.
+func (l *Logger) AssertLoggedInOrder(t testing.TB, expectations ...Expectation) bool {
+	t.Helper()
+
+	messages := l.Messages()
+
+	i := 0
+	for _, message := range messages {
+		if i < len(expectations) && expectations[i].Matches(message) {
+			i++
+		}
+	}
+
+	if i < len(expectations) {
+		t.Errorf("expected a message matching %s after %d matched expectations\n%s", expectations[i], i, l.describe())
+		return false
+	}
+
+	return true
+}
+
+func (l *Logger) describe() string {
+	messages := l.Messages()
+	if len(messages) == 0 {
+		return "no messages were logged"
+	}
+
+	lines := make([]string, 0, len(messages))
+	for _, message := range messages {
+		lines = append(lines, "    "+message.String())
+	}
+
+	return "logged messages:\n" + strings.Join(lines, "\n")
+}
+
+func sortedKeys(fields log.LogFields) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
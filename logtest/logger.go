@@ -0,0 +1,151 @@
+// Package logtest provides a capturing Logger and assertion helpers for
+// testing code that logs through github.com/go-nacelle/log.
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+)
+
+type (
+	// Logger is a log.Logger that records every message logged through it
+	// or any logger derived from it via WithFields or WithIndirectCaller.
+	Logger struct {
+		log.Logger
+		recorder *recorder
+	}
+
+	// Message is a message captured by a Logger.
+	Message struct {
+		Level   log.LogLevel
+		Fields  log.LogFields
+		Message string
+		Caller  string
+	}
+
+	// ConfigFunc is a function used to configure a Logger.
+	ConfigFunc func(*options)
+
+	options struct {
+		t testing.TB
+	}
+
+	recorder struct {
+		store  *store
+		fields log.LogFields
+	}
+
+	store struct {
+		t        testing.TB
+		messages []Message
+		mutex    sync.RWMutex
+	}
+)
+
+var _ log.MinimalLogger = &recorder{}
+
+// New creates a new capturing Logger.
+func New(configs ...ConfigFunc) *Logger {
+	options := &options{}
+	for _, f := range configs {
+		f(options)
+	}
+
+	recorder := &recorder{store: &store{t: options.t}}
+	return &Logger{Logger: log.FromMinimalLogger(recorder), recorder: recorder}
+}
+
+// WithTestingT causes every captured message to also be written via t.Log. The
+// test framework only displays this output for failing tests or when tests are
+// run in verbose mode.
+func WithTestingT(t testing.TB) ConfigFunc {
+	return func(o *options) { o.t = t }
+}
+
+// Messages returns a copy of the messages captured so far.
+func (l *Logger) Messages() []Message {
+	l.recorder.store.mutex.RLock()
+	defer l.recorder.store.mutex.RUnlock()
+
+	messages := make([]Message, len(l.recorder.store.messages))
+	copy(messages, l.recorder.store.messages)
+	return messages
+}
+
+// Reset discards all captured messages.
+func (l *Logger) Reset() {
+	l.recorder.store.mutex.Lock()
+	defer l.recorder.store.mutex.Unlock()
+
+	l.recorder.store.messages = nil
+}
+
+func (r *recorder) WithFields(fields log.LogFields) log.MinimalLogger {
+	if len(fields) == 0 {
+		return r
+	}
+
+	return &recorder{store: r.store, fields: merge(r.fields, fields)}
+}
+
+func (r *recorder) LogWithFields(level log.LogLevel, fields log.LogFields, format string, args ...interface{}) {
+	if r.store.t != nil {
+		r.store.t.Helper()
+	}
+
+	fields = merge(r.fields, fields)
+
+	caller, _ := fields["caller"].(string)
+	delete(fields, "caller")
+
+	r.store.record(Message{
+		Level:   level,
+		Fields:  fields,
+		Message: fmt.Sprintf(format, args...),
+		Caller:  caller,
+	})
+}
+
+func (r *recorder) Sync() error {
+	return nil
+}
+
+func (s *store) record(message Message) {
+	s.mutex.Lock()
+	s.messages = append(s.messages, message)
+	s.mutex.Unlock()
+
+	if s.t != nil {
+		s.t.Helper()
+		s.t.Log(message.String())
+	}
+}
+
+// String renders the message on a single line.
+func (m Message) String() string {
+	parts := []string{fmt.Sprintf("[%s]", strings.ToUpper(m.Level.String())), m.Message}
+	for _, key := range sortedKeys(m.Fields) {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, m.Fields[key]))
+	}
+
+	if m.Caller != "" {
+		parts = append(parts, fmt.Sprintf("caller=%s", m.Caller))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func merge(fields ...log.LogFields) log.LogFields {
+	merged := log.LogFields{}
+	for _, f := range fields {
+		for k, v := range f {
+			merged[k] = v
+		}
+	}
+
+	return merged
+}
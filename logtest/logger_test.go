@@ -0,0 +1,90 @@
+package logtest
+
+import (
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggerCapturesMessages(t *testing.T) {
+	logger := New()
+	logger.WithFields(log.LogFields{"a": 1}).InfoWithFields(log.LogFields{"b": 2}, "foo %d", 12)
+	logger.Warning("bar")
+
+	messages := logger.Messages()
+	require.Len(t, messages, 2)
+	assert.Equal(t, log.LevelInfo, messages[0].Level)
+	assert.Equal(t, "foo 12", messages[0].Message)
+	assert.Equal(t, log.LogFields{"a": 1, "b": 2}, messages[0].Fields)
+	assert.Equal(t, "logtest/logger_test.go:13", messages[0].Caller)
+	assert.Equal(t, log.LevelWarning, messages[1].Level)
+	assert.Equal(t, "bar", messages[1].Message)
+
+	logger.Reset()
+	assert.Empty(t, logger.Messages())
+}
+
+func TestLoggerAssertions(t *testing.T) {
+	logger := New(WithTestingT(t))
+	logger.InfoWithFields(log.LogFields{"id": 42}, "request started")
+	logger.Debug("processing")
+	logger.ErrorWithFields(log.LogFields{"id": 42}, "request failed: %s", "timeout")
+
+	assert.True(t, logger.AssertLogged(t, log.LevelInfo, "started"))
+	assert.True(t, logger.AssertLogged(t, log.LevelError, "timeout", log.LogFields{"id": 42}))
+	assert.True(t, logger.AssertNotLogged(t, log.LevelWarning, ""))
+	assert.True(t, logger.AssertNotLogged(t, log.LevelError, "timeout", log.LogFields{"id": 43}))
+	assert.True(t, logger.AssertLoggedInOrder(t,
+		Expect(log.LevelInfo, "started"),
+		Expect(log.LevelError, "failed"),
+	))
+}
+
+func TestLoggerAssertionFailures(t *testing.T) {
+	logger := New()
+	logger.Info("started")
+	logger.Error("failed")
+
+	mockT := &failureRecorder{TB: t}
+	assert.False(t, logger.AssertLogged(mockT, log.LevelInfo, "failed"))
+	assert.False(t, logger.AssertNotLogged(mockT, log.LevelError, "fail"))
+	assert.False(t, logger.AssertLoggedInOrder(mockT,
+		Expect(log.LevelError, "failed"),
+		Expect(log.LevelInfo, "started"),
+	))
+	assert.Equal(t, 3, mockT.failures)
+}
+
+type failureRecorder struct {
+	testing.TB
+	failures int
+}
+
+func (r *failureRecorder) Errorf(format string, args ...interface{}) {
+	r.failures++
+}
+
+func TestLoggerMarksHelpers(t *testing.T) {
+	mockT := &helperRecorder{TB: t}
+	logger := New(WithTestingT(mockT))
+	logger.Info("started")
+
+	assert.Equal(t, []string{"[INFO] started caller=logtest/logger_test.go:72"}, mockT.logs)
+	assert.Equal(t, 2, mockT.helpers)
+}
+
+type helperRecorder struct {
+	testing.TB
+	helpers int
+	logs    []string
+}
+
+func (r *helperRecorder) Helper() {
+	r.helpers++
+}
+
+func (r *helperRecorder) Log(args ...interface{}) {
+	r.logs = append(r.logs, args[0].(string))
+}
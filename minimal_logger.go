@@ -1,5 +1,10 @@
 package log
 
+import (
+	"fmt"
+	"time"
+)
+
 type (
 	MinimalLogger interface {
 		WithFields(LogFields) MinimalLogger
@@ -13,10 +18,12 @@ type (
 	}
 
 	logMessage struct {
-		level  LogLevel
-		fields LogFields
-		format string
-		args   []interface{}
+		timestamp time.Time
+		sequence  uint64
+		level     LogLevel
+		fields    LogFields
+		format    string
+		args      []interface{}
 	}
 )
 
@@ -44,10 +51,33 @@ func (sa *adapter) LogWithFields(level LogLevel, fields LogFields, format string
 	sa.logger.LogWithFields(level, addCaller(fields, sa.depth), format, args...)
 }
 
+func (sa *adapter) logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
+	fields = addCaller(fields, sa.depth)
+
+	if sequenced, ok := sa.logger.(sequencedLogger); ok {
+		return sequenced.logSequenced(level, fields, journal, format, args...)
+	}
+
+	sa.logger.LogWithFields(level, fields, format, args...)
+	return 0
+}
+
+func (sa *adapter) config() *baseWrapper {
+	return loggerConfig(sa.logger)
+}
+
+func (sa *adapter) formatTimestamp(t time.Time) interface{} {
+	return formatTimestamp(sa.logger, t)
+}
+
 func (sa *adapter) Sync() error {
 	return sa.logger.Sync()
 }
 
+func (sa *adapter) Close() error {
+	return closeLogger(sa.logger)
+}
+
 func (sa *adapter) Debug(format string, args ...interface{}) {
 	sa.logger.LogWithFields(LevelDebug, addCaller(nil, sa.depth), format, args...)
 }
@@ -64,6 +94,11 @@ func (sa *adapter) Error(format string, args ...interface{}) {
 	sa.logger.LogWithFields(LevelError, addCaller(nil, sa.depth), format, args...)
 }
 
+func (sa *adapter) Panic(format string, args ...interface{}) {
+	sa.logger.LogWithFields(LevelPanic, addCaller(nil, sa.depth), format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
 func (sa *adapter) Fatal(format string, args ...interface{}) {
 	sa.logger.LogWithFields(LevelFatal, addCaller(nil, sa.depth), format, args...)
 }
@@ -84,6 +119,22 @@ func (sa *adapter) ErrorWithFields(fields LogFields, format string, args ...inte
 	sa.logger.LogWithFields(LevelError, addCaller(fields, sa.depth), format, args...)
 }
 
+func (sa *adapter) PanicWithFields(fields LogFields, format string, args ...interface{}) {
+	sa.logger.LogWithFields(LevelPanic, addCaller(fields, sa.depth), format, args...)
+	panic(fmt.Sprintf(format, args...))
+}
+
 func (sa *adapter) FatalWithFields(fields LogFields, format string, args ...interface{}) {
 	sa.logger.LogWithFields(LevelFatal, addCaller(fields, sa.depth), format, args...)
 }
+
+// originalFields returns a copy of the message's fields along with a field
+// holding the sequence number assigned when the message was originally logged.
+func (m *logMessage) originalFields() LogFields {
+	fields := m.fields.clone()
+	if m.sequence != 0 {
+		fields[FieldOriginalSequence] = m.sequence
+	}
+
+	return fields
+}
@@ -0,0 +1,429 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// msgpackTimestampExt is the extension type reserved by the MessagePack spec for
+// timestamps.
+const msgpackTimestampExt = -1
+
+// msgpackExt is an extension value with a type for which no native decoding exists.
+type msgpackExt struct {
+	Type int8
+	Data []byte
+}
+
+// appendMsgpack appends the MessagePack encoding of the given value to buf. Values
+// without a native MessagePack representation are encoded as they would be by
+// encoding/json. Map keys are written in sorted order.
+func appendMsgpack(buf []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0)
+	case bool:
+		if v {
+			return append(buf, 0xc3)
+		}
+		return append(buf, 0xc2)
+	case int:
+		return appendMsgpackInt(buf, int64(v))
+	case int8:
+		return appendMsgpackInt(buf, int64(v))
+	case int16:
+		return appendMsgpackInt(buf, int64(v))
+	case int32:
+		return appendMsgpackInt(buf, int64(v))
+	case int64:
+		return appendMsgpackInt(buf, v)
+	case uint:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint8:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint16:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint32:
+		return appendMsgpackUint(buf, uint64(v))
+	case uint64:
+		return appendMsgpackUint(buf, v)
+	case float32:
+		buf = append(buf, 0xca)
+		return binary.BigEndian.AppendUint32(buf, math.Float32bits(v))
+	case float64:
+		buf = append(buf, 0xcb)
+		return binary.BigEndian.AppendUint64(buf, math.Float64bits(v))
+	case string:
+		return appendMsgpackString(buf, v)
+	case []byte:
+		return appendMsgpackBinary(buf, v)
+	case time.Time:
+		return appendMsgpackTime(buf, v)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return appendMsgpackInt(buf, i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return appendMsgpack(buf, f)
+		}
+		return appendMsgpackString(buf, string(v))
+	case LogFields:
+		return appendMsgpackMap(buf, v)
+	case map[string]interface{}:
+		return appendMsgpackMap(buf, v)
+	case []interface{}:
+		buf = appendMsgpackArrayHeader(buf, len(v))
+		for _, elem := range v {
+			buf = appendMsgpack(buf, elem)
+		}
+		return buf
+	case msgpackExt:
+		return appendMsgpackExt(buf, v.Type, v.Data)
+	case error:
+		return appendMsgpackString(buf, v.Error())
+	}
+
+	return appendMsgpack(buf, nativeValue(value))
+}
+
+func appendMsgpackInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendMsgpackUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+}
+
+func appendMsgpackUint(buf []byte, v uint64) []byte {
+	switch {
+	case v <= 0x7f:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(v))
+	}
+
+	return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+}
+
+func appendMsgpackString(buf []byte, v string) []byte {
+	switch n := len(v); {
+	case n <= 31:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xdb), uint32(n))
+	}
+
+	return append(buf, v...)
+}
+
+func appendMsgpackBinary(buf []byte, v []byte) []byte {
+	switch n := len(v); {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = binary.BigEndian.AppendUint16(append(buf, 0xc5), uint16(n))
+	default:
+		buf = binary.BigEndian.AppendUint32(append(buf, 0xc6), uint32(n))
+	}
+
+	return append(buf, v...)
+}
+
+func appendMsgpackArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xdc), uint16(n))
+	}
+
+	return binary.BigEndian.AppendUint32(append(buf, 0xdd), uint32(n))
+}
+
+func appendMsgpackMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xde), uint16(n))
+	}
+
+	return binary.BigEndian.AppendUint32(append(buf, 0xdf), uint32(n))
+}
+
+func appendMsgpackMap(buf []byte, m map[string]interface{}) []byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf = appendMsgpackMapHeader(buf, len(m))
+	for _, key := range keys {
+		buf = appendMsgpackString(buf, key)
+		buf = appendMsgpack(buf, m[key])
+	}
+
+	return buf
+}
+
+func appendMsgpackExt(buf []byte, typ int8, data []byte) []byte {
+	switch n := len(data); n {
+	case 1:
+		buf = append(buf, 0xd4, byte(typ))
+	case 2:
+		buf = append(buf, 0xd5, byte(typ))
+	case 4:
+		buf = append(buf, 0xd6, byte(typ))
+	case 8:
+		buf = append(buf, 0xd7, byte(typ))
+	case 16:
+		buf = append(buf, 0xd8, byte(typ))
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xc7, byte(n), byte(typ))
+		case n <= math.MaxUint16:
+			buf = append(binary.BigEndian.AppendUint16(append(buf, 0xc8), uint16(n)), byte(typ))
+		default:
+			buf = append(binary.BigEndian.AppendUint32(append(buf, 0xc9), uint32(n)), byte(typ))
+		}
+	}
+
+	return append(buf, data...)
+}
+
+// appendMsgpackTime encodes a time using the timestamp extension type. The 64-bit
+// format is used when possible, and the 96-bit format otherwise.
+func appendMsgpackTime(buf []byte, t time.Time) []byte {
+	sec, nsec := t.Unix(), uint64(t.Nanosecond())
+
+	if sec >= 0 && sec>>34 == 0 {
+		return appendMsgpackExt(buf, msgpackTimestampExt, binary.BigEndian.AppendUint64(nil, nsec<<34|uint64(sec)))
+	}
+
+	data := binary.BigEndian.AppendUint32(nil, uint32(nsec))
+	return appendMsgpackExt(buf, msgpackTimestampExt, binary.BigEndian.AppendUint64(data, uint64(sec)))
+}
+
+// msgpackDecoder reads MessagePack values from a stream. Maps are decoded as
+// map[string]interface{}, arrays as []interface{}, integers as int64 (or uint64 if
+// they overflow an int64), timestamps as time.Time, and other extension types as
+// msgpackExt.
+type msgpackDecoder struct {
+	r *bufio.Reader
+}
+
+func newMsgpackDecoder(r io.Reader) *msgpackDecoder {
+	return &msgpackDecoder{r: bufio.NewReader(r)}
+}
+
+// decodeMsgpack decodes a single value from the given data.
+func decodeMsgpack(data []byte) (interface{}, error) {
+	return newMsgpackDecoder(bytes.NewReader(data)).Decode()
+}
+
+// Decode reads the next value from the stream.
+func (d *msgpackDecoder) Decode() (interface{}, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return d.decodeMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return d.decodeArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return d.decodeString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.readLength(b - 0xc4)
+		if err != nil {
+			return nil, err
+		}
+		return d.read(n)
+	case 0xc7, 0xc8, 0xc9:
+		n, err := d.readLength(b - 0xc7)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeExt(n)
+	case 0xca:
+		v, err := d.readUint(4)
+		return float64(math.Float32frombits(uint32(v))), err
+	case 0xcb:
+		v, err := d.readUint(8)
+		return math.Float64frombits(v), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		v, err := d.readUint(1 << (b - 0xcc))
+		if err != nil || v > math.MaxInt64 {
+			return v, err
+		}
+		return int64(v), nil
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (b - 0xd0)
+		v, err := d.readUint(size)
+		shift := 64 - 8*size
+		return int64(v<<shift) >> shift, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.decodeExt(1 << (b - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.readLength(b - 0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(n)
+	case 0xdc, 0xdd:
+		n, err := d.readLength(b - 0xdc + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(n)
+	case 0xde, 0xdf:
+		n, err := d.readLength(b - 0xde + 1)
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(n)
+	}
+
+	return nil, fmt.Errorf("unsupported msgpack format 0x%x", b)
+}
+
+// readLength reads a big-endian length of 1, 2, or 4 bytes (selected by the
+// index 0, 1, or 2).
+func (d *msgpackDecoder) readLength(index byte) (int, error) {
+	n, err := d.readUint(1 << index)
+	return int(n), err
+}
+
+func (d *msgpackDecoder) readUint(size int) (uint64, error) {
+	data, err := d.read(size)
+	if err != nil {
+		return 0, err
+	}
+
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+
+	return v, nil
+}
+
+func (d *msgpackDecoder) read(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(d.r, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (d *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	data, err := d.read(n)
+	return string(data), err
+}
+
+func (d *msgpackDecoder) decodeArray(n int) (interface{}, error) {
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		value, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int) (interface{}, error) {
+	values := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := d.Decode()
+		if err != nil {
+			return nil, err
+		}
+
+		if s, ok := key.(string); ok {
+			values[s] = value
+		} else {
+			values[fmt.Sprintf("%v", key)] = value
+		}
+	}
+
+	return values, nil
+}
+
+func (d *msgpackDecoder) decodeExt(n int) (interface{}, error) {
+	typ, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := d.read(n)
+	if err != nil {
+		return nil, err
+	}
+
+	if int8(typ) != msgpackTimestampExt {
+		return msgpackExt{Type: int8(typ), Data: data}, nil
+	}
+
+	switch len(data) {
+	case 4:
+		return time.Unix(int64(binary.BigEndian.Uint32(data)), 0), nil
+	case 8:
+		v := binary.BigEndian.Uint64(data)
+		return time.Unix(int64(v&(1<<34-1)), int64(v>>34)), nil
+	case 12:
+		return time.Unix(int64(binary.BigEndian.Uint64(data[4:])), int64(binary.BigEndian.Uint32(data))), nil
+	}
+
+	return nil, fmt.Errorf("illegal msgpack timestamp length %d", len(data))
+}
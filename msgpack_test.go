@@ -0,0 +1,102 @@
+package log
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpackEncoding(t *testing.T) {
+	assert.Equal(t, []byte{0xc0}, appendMsgpack(nil, nil))
+	assert.Equal(t, []byte{0xc3}, appendMsgpack(nil, true))
+	assert.Equal(t, []byte{0x7f}, appendMsgpack(nil, 127))
+	assert.Equal(t, []byte{0xff}, appendMsgpack(nil, -1))
+	assert.Equal(t, []byte{0xd0, 0xdf}, appendMsgpack(nil, -33))
+	assert.Equal(t, []byte{0xcd, 0x01, 0x00}, appendMsgpack(nil, 256))
+	assert.Equal(t, []byte{0xa3, 'f', 'o', 'o'}, appendMsgpack(nil, "foo"))
+	assert.Equal(t, []byte{0xc4, 0x02, 0x01, 0x02}, appendMsgpack(nil, []byte{1, 2}))
+	assert.Equal(t, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}, appendMsgpack(nil, LogFields{"b": 2, "a": 1}))
+	assert.Equal(t, []byte{0x92, 0x01, 0xa1, 'x'}, appendMsgpack(nil, []interface{}{1, "x"}))
+}
+
+type msgpackTestStruct struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type msgpackTestLevel int
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	timestamp := time.Unix(1503939881, 123456789)
+	past := time.Unix(-100, 5)
+
+	testCases := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{nil, nil},
+		{false, false},
+		{42, int64(42)},
+		{-1000000, int64(-1000000)},
+		{int64(math.MinInt64), int64(math.MinInt64)},
+		{uint64(math.MaxUint64), uint64(math.MaxUint64)},
+		{uint32(70000), int64(70000)},
+		{float32(1.5), float64(1.5)},
+		{3.25, 3.25},
+		{"", ""},
+		{string(make([]byte, 300)), string(make([]byte, 300))},
+		{[]byte("raw"), []byte("raw")},
+		{timestamp, timestamp},
+		{past, past},
+		{errors.New("oops"), "oops"},
+		{msgpackTestLevel(3), int64(3)},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{map[string]int{"a": 1}, map[string]interface{}{"a": int64(1)}},
+		{msgpackTestStruct{Name: "x", Count: 2}, map[string]interface{}{"name": "x", "count": int64(2)}},
+		{&msgpackTestStruct{Name: "y"}, map[string]interface{}{"name": "y", "count": int64(0)}},
+		{msgpackExt{Type: 5, Data: []byte{1, 2, 3}}, msgpackExt{Type: 5, Data: []byte{1, 2, 3}}},
+		{
+			LogFields{"nested": LogFields{"list": []interface{}{1, "two", nil}}},
+			map[string]interface{}{"nested": map[string]interface{}{"list": []interface{}{int64(1), "two", nil}}},
+		},
+	}
+
+	for _, testCase := range testCases {
+		value, err := decodeMsgpack(appendMsgpack(nil, testCase.value))
+		require.Nil(t, err)
+
+		if expected, ok := testCase.expected.(time.Time); ok {
+			assert.True(t, expected.Equal(value.(time.Time)))
+			continue
+		}
+
+		assert.Equal(t, testCase.expected, value)
+	}
+}
+
+func TestMsgpackDecodeLargeCollections(t *testing.T) {
+	values := make([]interface{}, 20)
+	fields := LogFields{}
+	for i := range values {
+		values[i] = int64(i)
+		fields[string(rune('a'+i))] = int64(i)
+	}
+
+	value, err := decodeMsgpack(appendMsgpack(nil, values))
+	require.Nil(t, err)
+	assert.Equal(t, values, value)
+
+	value, err = decodeMsgpack(appendMsgpack(nil, fields))
+	require.Nil(t, err)
+	assert.Equal(t, map[string]interface{}(fields), value)
+}
+
+func TestMsgpackDecodeTruncated(t *testing.T) {
+	data := appendMsgpack(nil, "truncated")
+	_, err := decodeMsgpack(data[:4])
+	assert.NotNil(t, err)
+}
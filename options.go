@@ -0,0 +1,96 @@
+package log
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+type (
+	// LoggerConfigFunc is a function used to configure a logger created by InitLogger.
+	LoggerConfigFunc func(*loggerOptions)
+
+	loggerOptions struct {
+		clock            glock.Clock
+		exiter           func()
+		exitCode         int
+		fatalHooks       []FatalHook
+		fatalHookTimeout time.Duration
+		sequence         func() uint64
+		output           io.Writer
+	}
+)
+
+// WithClock sets the clock used to timestamp messages and to schedule reconnection
+// attempts to a network output.
+func WithClock(clock glock.Clock) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.clock = clock }
+}
+
+// WithExiter sets the function called after a message is logged at the fatal
+// level and all fatal hooks have run. By default, the process exits with the
+// configured exit code.
+func WithExiter(exiter func()) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.exiter = exiter }
+}
+
+// WithExitCode sets the status with which the process exits after a message is
+// logged at the fatal level. The default exit code is 1. This option has no effect
+// when combined with WithExiter.
+func WithExitCode(code int) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.exitCode = code }
+}
+
+// WithFatalHook adds a hook invoked after a message is logged at the fatal level and
+// before the process exits. Hooks are invoked in the order they were supplied, before
+// any hooks registered globally via RegisterFatalHook.
+func WithFatalHook(hook FatalHook) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.fatalHooks = append(o.fatalHooks, hook) }
+}
+
+// WithFatalHookTimeout sets the maximum duration for which fatal hooks are run before
+// the process exits. The default timeout is DefaultFatalHookTimeout.
+func WithFatalHookTimeout(timeout time.Duration) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.fatalHookTimeout = timeout }
+}
+
+// WithSequenceSource sets the function called to assign a sequence number to each
+// message. By default, messages are numbered consecutively starting at 1.
+func WithSequenceSource(next func() uint64) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.sequence = next }
+}
+
+// WithOutput sets the writer to which encoded messages are written. By default,
+// messages are written to stderr.
+func WithOutput(w io.Writer) LoggerConfigFunc {
+	return func(o *loggerOptions) { o.output = w }
+}
+
+func getLoggerOptions(configs []LoggerConfigFunc) *loggerOptions {
+	options := &loggerOptions{
+		clock:            glock.NewRealClock(),
+		exitCode:         1,
+		fatalHookTimeout: DefaultFatalHookTimeout,
+		sequence:         newSequenceCounter(),
+		output:           os.Stderr,
+	}
+
+	for _, f := range configs {
+		f(options)
+	}
+
+	if options.exiter == nil {
+		code := options.exitCode
+		options.exiter = func() { os.Exit(code) }
+	}
+
+	return options
+}
+
+func newSequenceCounter() func() uint64 {
+	var sequence uint64
+	return func() uint64 { return atomic.AddUint64(&sequence, 1) }
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitLoggerOptions(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	exited := false
+	sequence := uint64(100)
+
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "json"},
+		WithOutput(buffer),
+		WithClock(clock),
+		WithExiter(func() { exited = true }),
+		WithSequenceSource(func() uint64 { sequence += 10; return sequence }),
+	)
+	require.Nil(t, err)
+
+	logger.Info("foo")
+	logger.Fatal("bar")
+	assert.True(t, exited)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 2)
+
+	for i, message := range []string{"foo", "bar"} {
+		data := LogFields{}
+		require.Nil(t, json.Unmarshal([]byte(lines[i]), &data))
+		assert.Equal(t, message, data["message"])
+		assert.Equal(t, time.Unix(1503939881, 0).UTC().Format(JSONTimeFormat), data["timestamp"])
+		assert.Equal(t, float64(110+10*i), data["sequenceNumber"])
+	}
+}
+
+func TestRollupLoggerOptions(t *testing.T) {
+	logger := &testLogger{}
+	clock := glock.NewMockClock()
+	rollupLogger := NewRollupLogger(FromMinimalLogger(logger), time.Second, WithRollupClock(clock))
+
+	rollupLogger.Info("a")
+	rollupLogger.Info("a")
+	rollupLogger.Info("a")
+	assert.Len(t, logger.copy(), 1)
+
+	clock.BlockingAdvance(time.Second)
+	requireEventually(t, func() bool { return len(logger.copy()) == 2 })
+	assert.Equal(t, 2, logger.copy()[1].fields[FieldRollup])
+}
+
+func TestReplayLoggerOptions(t *testing.T) {
+	logger := &testLogger{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	replayLogger := NewReplayLogger(FromMinimalLogger(logger), LevelDebug, WithReplayClock(clock))
+
+	replayLogger.Debug("foo")
+	clock.Advance(time.Minute)
+	replayLogger.Replay(LevelError)
+
+	messages := logger.copy()
+	require.Len(t, messages, 2)
+	assert.Equal(t, time.Unix(1503939881, 0).UTC(), messages[1].fields[FieldReplayTime])
+}
+
+func TestReplayLoggerLevels(t *testing.T) {
+	logger := &testLogger{}
+	replayLogger := NewReplayLogger(FromMinimalLogger(logger), LevelDebug, WithReplayLevels(LevelInfo))
+
+	replayLogger.Debug("foo")
+	replayLogger.Info("bar")
+	replayLogger.Warning("baz")
+	replayLogger.Replay(LevelError)
+
+	messages := logger.copy()
+	require.Len(t, messages, 5)
+	assert.Equal(t, "foo", messages[3].format)
+	assert.Equal(t, "bar", messages[4].format)
+}
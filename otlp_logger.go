@@ -0,0 +1,490 @@
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/derision-test/glock"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+const (
+	// OTLPScopeName is the instrumentation scope name attached to exported log records.
+	OTLPScopeName = "github.com/go-nacelle/log"
+
+	// DefaultOTLPEndpoint is the default OTLP/HTTP logs endpoint of a local collector.
+	DefaultOTLPEndpoint = "http://localhost:4318/v1/logs"
+)
+
+var (
+	ErrIllegalOTLPProtocol = fmt.Errorf("illegal OTLP protocol")
+	ErrOTLPExport          = fmt.Errorf("OTLP export failed")
+)
+
+var otlpSeverityNumbers = map[LogLevel]int{
+	LevelFatal:   21, // FATAL
+	LevelPanic:   21, // FATAL
+	LevelError:   17, // ERROR
+	LevelWarning: 13, // WARN
+	LevelInfo:    9,  // INFO
+	LevelDebug:   5,  // DEBUG
+}
+
+// otlpResourceAliases maps initial field names to the semantic convention names of
+// the resource attributes they populate.
+var otlpResourceAliases = map[string]string{
+	"service": "service.name",
+	"version": "service.version",
+}
+
+type otlpLogger struct {
+	endpoint      string
+	json          bool
+	headers       map[string]string
+	client        *http.Client
+	initialFields LogFields
+	resource      []otlpKeyValue
+	queue         *batchQueue
+}
+
+type otlpKeyValue struct {
+	key   string
+	value interface{}
+}
+
+// newOTLPLogger creates a sink that exports batches of log records to an OTLP/HTTP
+// endpoint. The initial fields are exported as resource attributes rather than as
+// attributes of each record.
+func newOTLPLogger(c *Config, clock glock.Clock) (*otlpLogger, error) {
+	if c.LogOTLPProtocol != "" && c.LogOTLPProtocol != "http/protobuf" && c.LogOTLPProtocol != "http/json" {
+		return nil, ErrIllegalOTLPProtocol
+	}
+
+	endpoint := c.LogOTLPEndpoint
+	if endpoint == "" {
+		endpoint = DefaultOTLPEndpoint
+	}
+
+	l := &otlpLogger{
+		endpoint:      endpoint,
+		json:          c.LogOTLPProtocol == "http/json",
+		headers:       c.LogOTLPHeaders,
+		client:        &http.Client{Timeout: streamWriteTimeout},
+		initialFields: c.LogInitialFields,
+		resource:      otlpResourceAttributes(c.LogInitialFields),
+	}
+
+	l.queue = newBatchQueue(c, clock, l.send)
+	l.queue.onDrop = reportDrops(l, clock)
+
+	return l, nil
+}
+
+func otlpResourceAttributes(fields LogFields) []otlpKeyValue {
+	attributes := map[string]interface{}{}
+	for key, value := range fields {
+		attributes[key] = value
+	}
+
+	for alias, key := range otlpResourceAliases {
+		if value, ok := fields[alias]; ok {
+			if _, ok := fields[key]; !ok {
+				delete(attributes, alias)
+				attributes[key] = value
+			}
+		}
+	}
+
+	return otlpKeyValues(attributes)
+}
+
+func (l *otlpLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	attributes := map[string]interface{}{}
+	for key, value := range fields {
+		if initialValue, ok := l.initialFields[key]; ok && reflect.DeepEqual(initialValue, value) {
+			continue
+		}
+
+		attributes[key] = value
+	}
+
+	traceID := otlpHexField(attributes, "trace_id", 16)
+	spanID := otlpHexField(attributes, "span_id", 8)
+
+	var flags uint32
+	if value := otlpHexField(attributes, "trace_flags", 1); value != nil {
+		flags = uint32(value[0])
+	}
+
+	record := otlpRecord{
+		timestamp:  timestamp,
+		level:      level,
+		body:       msg,
+		attributes: otlpKeyValues(attributes),
+		traceID:    traceID,
+		spanID:     spanID,
+		flags:      flags,
+	}
+
+	if l.json {
+		data, err := json.Marshal(record.jsonValue())
+		if err != nil {
+			return err
+		}
+
+		l.queue.Add("", data)
+	} else {
+		l.queue.Add("", record.appendProto(nil))
+	}
+
+	return nil
+}
+
+func (l *otlpLogger) Sync() error {
+	return l.queue.Sync()
+}
+
+// Close exports all buffered records and stops the background goroutine.
+func (l *otlpLogger) Close() error {
+	err := l.queue.Close()
+	l.client.CloseIdleConnections()
+	return err
+}
+
+// otlpHexField removes and returns the decoded value of a hex-encoded field of the
+// given size. If the field is absent or malformed, it is left in place and nil is
+// returned.
+func otlpHexField(attributes map[string]interface{}, key string, size int) []byte {
+	value, ok := attributes[key].(string)
+	if !ok {
+		return nil
+	}
+
+	decoded, err := hex.DecodeString(value)
+	if err != nil || len(decoded) != size {
+		return nil
+	}
+
+	delete(attributes, key)
+	return decoded
+}
+
+func (l *otlpLogger) send(entries []batchEntry) error {
+	var body []byte
+	contentType := "application/x-protobuf"
+
+	if l.json {
+		records := make([]json.RawMessage, 0, len(entries))
+		for _, entry := range entries {
+			records = append(records, entry.data)
+		}
+
+		var err error
+		if body, err = json.Marshal(l.jsonRequest(records)); err != nil {
+			return err
+		}
+
+		contentType = "application/json"
+	} else {
+		body = l.protoRequest(entries)
+	}
+
+	return postHTTP(l.client, l.endpoint, contentType, l.headers, body, false, ErrOTLPExport)
+}
+
+// protoRequest encodes an ExportLogsServiceRequest containing a single resource and
+// scope. Each entry holds an encoded LogRecord.
+func (l *otlpLogger) protoRequest(entries []batchEntry) []byte {
+	var scope []byte
+	scope = protowire.AppendTag(scope, 1, protowire.BytesType)
+	scope = protowire.AppendBytes(scope, protowire.AppendString(protowire.AppendTag(nil, 1, protowire.BytesType), OTLPScopeName))
+	for _, entry := range entries {
+		scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+		scope = protowire.AppendBytes(scope, entry.data)
+	}
+
+	var resource []byte
+	for _, attribute := range l.resource {
+		resource = protowire.AppendTag(resource, 1, protowire.BytesType)
+		resource = protowire.AppendBytes(resource, attribute.appendProto(nil))
+	}
+
+	var resourceLogs []byte
+	resourceLogs = protowire.AppendTag(resourceLogs, 1, protowire.BytesType)
+	resourceLogs = protowire.AppendBytes(resourceLogs, resource)
+	resourceLogs = protowire.AppendTag(resourceLogs, 2, protowire.BytesType)
+	resourceLogs = protowire.AppendBytes(resourceLogs, scope)
+
+	request := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendBytes(request, resourceLogs)
+}
+
+func (l *otlpLogger) jsonRequest(records []json.RawMessage) interface{} {
+	return map[string]interface{}{
+		"resourceLogs": []interface{}{
+			map[string]interface{}{
+				"resource": map[string]interface{}{
+					"attributes": otlpJSONKeyValues(l.resource),
+				},
+				"scopeLogs": []interface{}{
+					map[string]interface{}{
+						"scope":      map[string]interface{}{"name": OTLPScopeName},
+						"logRecords": records,
+					},
+				},
+			},
+		},
+	}
+}
+
+type otlpRecord struct {
+	timestamp  time.Time
+	level      LogLevel
+	body       string
+	attributes []otlpKeyValue
+	traceID    []byte
+	spanID     []byte
+	flags      uint32
+}
+
+func (r otlpRecord) appendProto(buf []byte) []byte {
+	buf = protowire.AppendTag(buf, 1, protowire.Fixed64Type)
+	buf = protowire.AppendFixed64(buf, uint64(r.timestamp.UnixNano()))
+	buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+	buf = protowire.AppendVarint(buf, uint64(otlpSeverityNumbers[r.level]))
+	buf = protowire.AppendTag(buf, 3, protowire.BytesType)
+	buf = protowire.AppendString(buf, r.level.String())
+	buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+	buf = protowire.AppendBytes(buf, appendOTLPAnyValue(nil, r.body))
+
+	for _, attribute := range r.attributes {
+		buf = protowire.AppendTag(buf, 6, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, attribute.appendProto(nil))
+	}
+
+	if r.flags != 0 {
+		buf = protowire.AppendTag(buf, 8, protowire.Fixed32Type)
+		buf = protowire.AppendFixed32(buf, r.flags)
+	}
+	if r.traceID != nil {
+		buf = protowire.AppendTag(buf, 9, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, r.traceID)
+	}
+	if r.spanID != nil {
+		buf = protowire.AppendTag(buf, 10, protowire.BytesType)
+		buf = protowire.AppendBytes(buf, r.spanID)
+	}
+
+	buf = protowire.AppendTag(buf, 11, protowire.Fixed64Type)
+	return protowire.AppendFixed64(buf, uint64(r.timestamp.UnixNano()))
+}
+
+func (r otlpRecord) jsonValue() interface{} {
+	timestamp := strconv.FormatInt(r.timestamp.UnixNano(), 10)
+
+	record := map[string]interface{}{
+		"timeUnixNano":         timestamp,
+		"observedTimeUnixNano": timestamp,
+		"severityNumber":       otlpSeverityNumbers[r.level],
+		"severityText":         r.level.String(),
+		"body":                 otlpJSONAnyValue(r.body),
+		"attributes":           otlpJSONKeyValues(r.attributes),
+	}
+
+	if r.flags != 0 {
+		record["flags"] = r.flags
+	}
+	if r.traceID != nil {
+		record["traceId"] = hex.EncodeToString(r.traceID)
+	}
+	if r.spanID != nil {
+		record["spanId"] = hex.EncodeToString(r.spanID)
+	}
+
+	return record
+}
+
+func (kv otlpKeyValue) appendProto(buf []byte) []byte {
+	buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+	buf = protowire.AppendString(buf, kv.key)
+	buf = protowire.AppendTag(buf, 2, protowire.BytesType)
+	return protowire.AppendBytes(buf, appendOTLPAnyValue(nil, kv.value))
+}
+
+// otlpKeyValues returns the normalized attributes sorted by key.
+func otlpKeyValues(attributes map[string]interface{}) []otlpKeyValue {
+	keys := make([]string, 0, len(attributes))
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, key := range keys {
+		kvs = append(kvs, otlpKeyValue{key: key, value: otlpNormalize(attributes[key])})
+	}
+
+	return kvs
+}
+
+// otlpNormalize converts a value into one of the types representable by an OTLP
+// AnyValue: nil, bool, int64, float64, string, []byte, []interface{}, or
+// []otlpKeyValue. Values of other types are round-tripped through encoding/json.
+func otlpNormalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil, bool, int64, float64, string, []byte, []otlpKeyValue:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case error:
+		return v.Error()
+	case LogFields:
+		return otlpKeyValues(v)
+	case map[string]interface{}:
+		return otlpKeyValues(v)
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if u := rv.Uint(); u <= math.MaxInt64 {
+			return int64(u)
+		}
+		return float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+
+		values := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, otlpNormalize(rv.Index(i).Interface()))
+		}
+		return values
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]interface{}, rv.Len())
+			for _, key := range rv.MapKeys() {
+				m[key.String()] = rv.MapIndex(key).Interface()
+			}
+			return otlpKeyValues(m)
+		}
+	}
+
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(serialized)
+	}
+
+	return otlpNormalize(decoded)
+}
+
+// appendOTLPAnyValue encodes a normalized value as an AnyValue message. A nil value
+// is encoded as an empty message.
+func appendOTLPAnyValue(buf []byte, value interface{}) []byte {
+	switch v := otlpNormalize(value).(type) {
+	case string:
+		buf = protowire.AppendTag(buf, 1, protowire.BytesType)
+		return protowire.AppendString(buf, v)
+	case bool:
+		buf = protowire.AppendTag(buf, 2, protowire.VarintType)
+		return protowire.AppendVarint(buf, protowire.EncodeBool(v))
+	case int64:
+		buf = protowire.AppendTag(buf, 3, protowire.VarintType)
+		return protowire.AppendVarint(buf, uint64(v))
+	case float64:
+		buf = protowire.AppendTag(buf, 4, protowire.Fixed64Type)
+		return protowire.AppendFixed64(buf, math.Float64bits(v))
+	case []interface{}:
+		var array []byte
+		for _, elem := range v {
+			array = protowire.AppendTag(array, 1, protowire.BytesType)
+			array = protowire.AppendBytes(array, appendOTLPAnyValue(nil, elem))
+		}
+		buf = protowire.AppendTag(buf, 5, protowire.BytesType)
+		return protowire.AppendBytes(buf, array)
+	case []otlpKeyValue:
+		var list []byte
+		for _, kv := range v {
+			list = protowire.AppendTag(list, 1, protowire.BytesType)
+			list = protowire.AppendBytes(list, kv.appendProto(nil))
+		}
+		buf = protowire.AppendTag(buf, 6, protowire.BytesType)
+		return protowire.AppendBytes(buf, list)
+	case []byte:
+		buf = protowire.AppendTag(buf, 7, protowire.BytesType)
+		return protowire.AppendBytes(buf, v)
+	}
+
+	return buf
+}
+
+// otlpJSONAnyValue returns the OTLP/JSON representation of a normalized value.
+// Integers are encoded as strings and bytes as base64, as required by the protobuf
+// JSON mapping.
+func otlpJSONAnyValue(value interface{}) map[string]interface{} {
+	switch v := otlpNormalize(value).(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case int64:
+		return map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		return map[string]interface{}{"doubleValue": v}
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			values = append(values, otlpJSONAnyValue(elem))
+		}
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case []otlpKeyValue:
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": otlpJSONKeyValues(v)}}
+	case []byte:
+		return map[string]interface{}{"bytesValue": v}
+	}
+
+	return map[string]interface{}{}
+}
+
+func otlpJSONKeyValues(kvs []otlpKeyValue) []interface{} {
+	values := make([]interface{}, 0, len(kvs))
+	for _, kv := range kvs {
+		values = append(values, map[string]interface{}{"key": kv.key, "value": otlpJSONAnyValue(kv.value)})
+	}
+
+	return values
+}
@@ -0,0 +1,196 @@
+package log
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestOTLPLoggerJSON(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:         "info",
+		LogEncoding:      "otlp",
+		LogOTLPEndpoint:  server.URL + "/v1/logs",
+		LogOTLPProtocol:  "http/json",
+		LogOTLPHeaders:   map[string]string{"Authorization": "token"},
+		LogInitialFields: LogFields{"service": "api", "version": "1.2.3", "region": "us"},
+	}, WithClock(glock.NewMockClockAt(time.Unix(1503939881, 5))))
+	require.Nil(t, err)
+
+	logger.WarningWithFields(LogFields{
+		"attr1":       4321,
+		"nested":      LogFields{"ok": true, "ratio": 0.5},
+		"list":        []string{"a"},
+		"trace_id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span_id":     "00f067aa0ba902b7",
+		"trace_flags": "01",
+	}, "test 1234")
+	require.Nil(t, logger.Sync())
+
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "application/json", server.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, "token", server.requests[0].Header.Get("Authorization"))
+	assert.Equal(t, "/v1/logs", server.requests[0].URL.Path)
+
+	var payload struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]interface{} `json:"attributes"`
+			} `json:"resource"`
+			ScopeLogs []struct {
+				Scope      map[string]interface{}   `json:"scope"`
+				LogRecords []map[string]interface{} `json:"logRecords"`
+			} `json:"scopeLogs"`
+		} `json:"resourceLogs"`
+	}
+	require.Nil(t, json.Unmarshal(server.Bodies()[0], &payload))
+	require.Len(t, payload.ResourceLogs, 1)
+
+	assert.Equal(t, []map[string]interface{}{
+		{"key": "region", "value": map[string]interface{}{"stringValue": "us"}},
+		{"key": "service.name", "value": map[string]interface{}{"stringValue": "api"}},
+		{"key": "service.version", "value": map[string]interface{}{"stringValue": "1.2.3"}},
+	}, payload.ResourceLogs[0].Resource.Attributes)
+
+	require.Len(t, payload.ResourceLogs[0].ScopeLogs, 1)
+	assert.Equal(t, OTLPScopeName, payload.ResourceLogs[0].ScopeLogs[0].Scope["name"])
+	require.Len(t, payload.ResourceLogs[0].ScopeLogs[0].LogRecords, 1)
+	record := payload.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+
+	assert.Equal(t, "1503939881000000005", record["timeUnixNano"])
+	assert.Equal(t, float64(13), record["severityNumber"])
+	assert.Equal(t, "warning", record["severityText"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "test 1234"}, record["body"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", record["spanId"])
+	assert.Equal(t, float64(1), record["flags"])
+
+	attributes := map[string]interface{}{}
+	for _, attribute := range record["attributes"].([]interface{}) {
+		kv := attribute.(map[string]interface{})
+		attributes[kv["key"].(string)] = kv["value"]
+	}
+
+	assert.Equal(t, map[string]interface{}{"intValue": "4321"}, attributes["attr1"])
+	assert.Equal(t, map[string]interface{}{"arrayValue": map[string]interface{}{"values": []interface{}{map[string]interface{}{"stringValue": "a"}}}}, attributes["list"])
+	assert.Equal(t, map[string]interface{}{"kvlistValue": map[string]interface{}{"values": []interface{}{
+		map[string]interface{}{"key": "ok", "value": map[string]interface{}{"boolValue": true}},
+		map[string]interface{}{"key": "ratio", "value": map[string]interface{}{"doubleValue": 0.5}},
+	}}}, attributes["nested"])
+	assert.Contains(t, attributes, "caller")
+	assert.NotContains(t, attributes, "service")
+	assert.NotContains(t, attributes, "trace_id")
+}
+
+func TestOTLPLoggerProtobuf(t *testing.T) {
+	server := newHTTPTestServer()
+	defer server.Close()
+
+	logger, err := newOTLPLogger(&Config{
+		LogOTLPEndpoint:  server.URL,
+		LogInitialFields: LogFields{"service": "api"},
+	}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	timestamp := time.Unix(1503939881, 0)
+	require.Nil(t, logger.Log(timestamp, LevelError, LogFields{"service": "api", "attr1": -2, "pi": 3.5}, "A"))
+	require.Nil(t, logger.Log(timestamp, LevelDebug, nil, "B"))
+	require.Nil(t, logger.Sync())
+
+	require.Len(t, server.requests, 1)
+	assert.Equal(t, "application/x-protobuf", server.requests[0].Header.Get("Content-Type"))
+
+	request := parseTestProto(t, server.Bodies()[0])
+	resourceLogs := parseTestProto(t, request[1][0].([]byte))
+
+	resource := parseTestProto(t, resourceLogs[1][0].([]byte))
+	require.Len(t, resource[1], 1)
+	serviceName := parseTestProto(t, resource[1][0].([]byte))
+	assert.Equal(t, "service.name", string(serviceName[1][0].([]byte)))
+	assert.Equal(t, "api", string(parseTestProto(t, serviceName[2][0].([]byte))[1][0].([]byte)))
+
+	scopeLogs := parseTestProto(t, resourceLogs[2][0].([]byte))
+	scope := parseTestProto(t, scopeLogs[1][0].([]byte))
+	assert.Equal(t, OTLPScopeName, string(scope[1][0].([]byte)))
+	require.Len(t, scopeLogs[2], 2)
+
+	record := parseTestProto(t, scopeLogs[2][0].([]byte))
+	assert.Equal(t, uint64(timestamp.UnixNano()), record[1][0])
+	assert.Equal(t, uint64(17), record[2][0])
+	assert.Equal(t, "error", string(record[3][0].([]byte)))
+	assert.Equal(t, "A", string(parseTestProto(t, record[5][0].([]byte))[1][0].([]byte)))
+	require.Len(t, record[6], 2)
+
+	attr1 := parseTestProto(t, record[6][0].([]byte))
+	assert.Equal(t, "attr1", string(attr1[1][0].([]byte)))
+	assert.Equal(t, int64(-2), int64(parseTestProto(t, attr1[2][0].([]byte))[3][0].(uint64)))
+
+	pi := parseTestProto(t, record[6][1].([]byte))
+	assert.Equal(t, "pi", string(pi[1][0].([]byte)))
+	assert.Equal(t, 3.5, math.Float64frombits(parseTestProto(t, pi[2][0].([]byte))[4][0].(uint64)))
+
+	record = parseTestProto(t, scopeLogs[2][1].([]byte))
+	assert.Equal(t, uint64(5), record[2][0])
+	assert.Nil(t, record[6])
+}
+
+func TestOTLPLoggerRetries(t *testing.T) {
+	server := newHTTPTestServer(http.StatusServiceUnavailable)
+	defer server.Close()
+
+	logger, err := newOTLPLogger(&Config{LogOTLPEndpoint: server.URL, LogBatchMaxRetries: 1}, glock.NewRealClock())
+	require.Nil(t, err)
+
+	require.Nil(t, logger.Log(time.Now(), LevelInfo, nil, "A"))
+	require.Nil(t, logger.Sync())
+	assert.Len(t, server.Bodies(), 2)
+}
+
+func TestOTLPLoggerIllegalProtocol(t *testing.T) {
+	_, err := newOTLPLogger(&Config{LogOTLPProtocol: "grpc"}, glock.NewRealClock())
+	assert.Equal(t, ErrIllegalOTLPProtocol, err)
+}
+
+// parseTestProto decodes the top-level fields of a protobuf message. Varint and
+// fixed-width values are returned as uint64 and length-delimited values as []byte.
+func parseTestProto(t *testing.T, data []byte) map[protowire.Number][]interface{} {
+	fields := map[protowire.Number][]interface{}{}
+
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		require.True(t, n > 0)
+		data = data[n:]
+
+		var value interface{}
+		switch typ {
+		case protowire.VarintType:
+			value, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			value = uint64(v)
+		case protowire.Fixed64Type:
+			value, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+
+		require.True(t, n > 0)
+		data = data[n:]
+		fields[num] = append(fields[num], value)
+	}
+
+	return fields
+}
@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+type (
+	// RecoverAction determines what happens after a recovered panic is logged.
+	RecoverAction int
+
+	// RecoverConfigFunc is a function used to configure panic recovery.
+	RecoverConfigFunc func(*recoverOptions)
+
+	recoverOptions struct {
+		action RecoverAction
+		level  LogLevel
+	}
+)
+
+const (
+	// RecoverRepanic re-panics with the recovered value after it is logged.
+	RecoverRepanic RecoverAction = iota
+
+	// RecoverExit logs the recovered value at the fatal level, which causes the
+	// logger to run its fatal hooks and exit the process.
+	RecoverExit
+
+	// RecoverSwallow discards the recovered value after it is logged.
+	RecoverSwallow
+)
+
+const (
+	// FieldPanic is a field assigned to a message logged for a recovered panic.
+	// Its value is the formatted panic value.
+	FieldPanic = "panic"
+
+	// FieldStack is a field assigned to a message logged for a recovered panic.
+	// Its value is the stack trace of the panicking goroutine.
+	FieldStack = "stack"
+
+	// FieldGoroutine is a field assigned to a message logged for a recovered panic.
+	// Its value is the identifier of the panicking goroutine.
+	FieldGoroutine = "goroutine"
+)
+
+// WithRecoverAction sets the action taken after a recovered panic is logged. The
+// default action is RecoverRepanic.
+func WithRecoverAction(action RecoverAction) RecoverConfigFunc {
+	return func(o *recoverOptions) { o.action = action }
+}
+
+// WithRecoverLevel sets the level at which recovered panics are logged when the
+// action is not RecoverExit. The default level is LevelError.
+func WithRecoverLevel(level LogLevel) RecoverConfigFunc {
+	return func(o *recoverOptions) { o.level = level }
+}
+
+// RecoverAndLog recovers a panic in the current goroutine and logs it along with its
+// stack trace and goroutine identifier. The logger is synced before the configured
+// action is taken. This function must be deferred directly, as in:
+//
+//	defer log.RecoverAndLog(logger)
+func RecoverAndLog(logger Logger, configs ...RecoverConfigFunc) {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	options := &recoverOptions{action: RecoverRepanic, level: LevelError}
+	for _, f := range configs {
+		f(options)
+	}
+
+	level := options.level
+	if options.action == RecoverExit {
+		level = LevelFatal
+	}
+
+	stack := debug.Stack()
+	fields := LogFields{
+		FieldPanic:     fmt.Sprintf("%v", value),
+		FieldStack:     string(stack),
+		FieldGoroutine: goroutineID(stack),
+	}
+
+	if caller := getPanicCaller(); caller != "" {
+		fields["caller"] = caller
+	}
+
+	logger.LogWithFields(level, fields, "recovered from panic: %v", value)
+	logger.Sync()
+
+	if options.action == RecoverRepanic {
+		panic(value)
+	}
+}
+
+// Go runs the given function in a new goroutine supervised by RecoverAndLog.
+func Go(logger Logger, f func(), configs ...RecoverConfigFunc) {
+	go func() {
+		defer RecoverAndLog(logger, configs...)
+		f()
+	}()
+}
+
+// goroutineID parses the goroutine identifier from the header of a stack trace
+// produced by debug.Stack, which has the form "goroutine 123 [running]:".
+func goroutineID(stack []byte) uint64 {
+	line := stack
+	if idx := bytes.IndexByte(line, '\n'); idx >= 0 {
+		line = line[:idx]
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) < 2 || fields[0] != "goroutine" {
+		return 0
+	}
+
+	id, _ := strconv.ParseUint(fields[1], 10, 64)
+	return id
+}
+
+// getPanicCaller returns the location at which the current panic was raised. This is
+// the first frame outside of the runtime package below the call to runtime.gopanic.
+func getPanicCaller() string {
+	pcs := make([]uintptr, 64)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			return fmt.Sprintf("%s:%d", trimPath(frame.File), frame.Line)
+		}
+
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecoverAndLogRepanics(t *testing.T) {
+	sink := &syncingLogSink{MockLogSink: NewMockLogSink()}
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	assert.PanicsWithValue(t, "oops", func() {
+		defer RecoverAndLog(logger)
+		panic("oops")
+	})
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelError, history[0].Arg1)
+	assert.Equal(t, "recovered from panic: oops", history[0].Arg3)
+	assert.Equal(t, "oops", history[0].Arg2[FieldPanic])
+	assert.Contains(t, history[0].Arg2[FieldStack], "TestRecoverAndLogRepanics")
+	assert.NotZero(t, history[0].Arg2[FieldGoroutine])
+	assert.Equal(t, 1, sink.synced)
+
+	// Note: this value refers to the line number containing the call to `panic` in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "log/recover_test.go:18", history[0].Arg2["caller"])
+}
+
+func TestRecoverAndLogSwallows(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	assert.NotPanics(t, func() {
+		defer RecoverAndLog(logger, WithRecoverAction(RecoverSwallow), WithRecoverLevel(LevelWarning))
+		var m map[string]int
+		m["x"] = 1
+	})
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelWarning, history[0].Arg1)
+	assert.Equal(t, "assignment to entry in nil map", history[0].Arg2[FieldPanic])
+	assert.True(t, strings.HasPrefix(history[0].Arg2["caller"].(string), "log/recover_test.go:"))
+}
+
+func TestRecoverAndLogExits(t *testing.T) {
+	sink := NewMockLogSink()
+	exited := false
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() { exited = true })
+
+	assert.NotPanics(t, func() {
+		defer RecoverAndLog(logger, WithRecoverAction(RecoverExit))
+		panic("oops")
+	})
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelFatal, history[0].Arg1)
+	assert.True(t, exited)
+}
+
+func TestRecoverAndLogNoPanic(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	func() {
+		defer RecoverAndLog(logger)
+	}()
+
+	assert.Empty(t, sink.LogFunc.History())
+}
+
+func TestGo(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	Go(logger, func() { panic("oops") }, WithRecoverAction(RecoverSwallow))
+
+	requireEventually(t, func() bool { return len(sink.LogFunc.History()) == 1 })
+	assert.Equal(t, "oops", sink.LogFunc.History()[0].Arg2[FieldPanic])
+}
+
+func TestGoroutineID(t *testing.T) {
+	assert.Equal(t, uint64(123), goroutineID([]byte("goroutine 123 [running]:\nmain.main()")))
+	assert.Equal(t, uint64(0), goroutineID([]byte("garbage")))
+}
@@ -1,15 +1,34 @@
 package log
 
 import (
+	"fmt"
+	"io"
 	"sync"
+	"time"
 
 	"github.com/derision-test/glock"
 )
 
-// FieldReplay is a field assigned to a message that has
-// been replayed at a different log level. Its value is equal
-// to the original log level.
-const FieldReplay = "replayed-from-level"
+const (
+	// FieldReplay is a field assigned to a message that has
+	// been replayed at a different log level. Its value is equal
+	// to the original log level.
+	FieldReplay = "replayed-from-level"
+
+	// FieldReplayTime is a field assigned to a message that has
+	// been replayed at a different log level. Its value is equal
+	// to the time at which the message was originally logged.
+	FieldReplayTime = "replayed-from-time"
+
+	// FieldReplayMessages is a field assigned to a bundled replay
+	// message. Its value is a list of the journaled messages in the
+	// order they were logged.
+	FieldReplayMessages = "replayed-messages"
+)
+
+// replayBundleInterval is the duration for which messages journaled after a
+// bundled replay are collected before they are replayed.
+const replayBundleInterval = time.Second
 
 type (
 	// ReplayLogger is a Logger that provides a way to replay a sequence of
@@ -23,8 +42,21 @@ type (
 		Replay(LogLevel)
 	}
 
+	// ReplayLoggerConfigFunc is a function used to configure a replay logger.
+	ReplayLoggerConfigFunc func(*replayLoggerOptions)
+
+	replayLoggerOptions struct {
+		clock       glock.Clock
+		levels      []LogLevel
+		destination Logger
+		writer      io.Writer
+		bundle      bool
+	}
+
 	replayLogger struct {
 		logger        Logger
+		destination   Logger
+		fields        LogFields
 		sharedJournal *sharedJournal
 	}
 
@@ -35,7 +67,10 @@ type (
 
 	sharedJournal struct {
 		clock       glock.Clock
+		destination Logger
+		bundle      bool
 		messages    []*journaledMessage
+		pending     []*journaledMessage
 		levels      []LogLevel
 		replayingAt *LogLevel
 		mutex       sync.RWMutex
@@ -43,15 +78,59 @@ type (
 
 	journaledMessage struct {
 		logger  Logger
+		fields  LogFields
 		message logMessage
 	}
 )
 
 var _ MinimalLogger = &replayLogger{}
 
-// NewReplayLogger creates a ReplayLogger wrapping the given logger.
-func NewReplayLogger(logger Logger, levels ...LogLevel) ReplayLogger {
-	return fromReplayLogger(newReplayLogger(logger, glock.NewRealClock(), levels...))
+// NewReplayLogger creates a ReplayLogger wrapping the given logger that journals
+// messages at the given level. The supplied config functions control which other
+// levels are journaled and where and how journaled messages are replayed.
+func NewReplayLogger(logger Logger, level LogLevel, configs ...ReplayLoggerConfigFunc) ReplayLogger {
+	options := &replayLoggerOptions{clock: glock.NewRealClock(), levels: []LogLevel{level}}
+	for _, f := range configs {
+		f(options)
+	}
+
+	return fromReplayLogger(newReplayLoggerWithOptions(logger, options, options.levels...))
+}
+
+// WithReplayClock sets the clock used to timestamp journaled messages.
+func WithReplayClock(clock glock.Clock) ReplayLoggerConfigFunc {
+	return func(o *replayLoggerOptions) { o.clock = clock }
+}
+
+// WithReplayLevels adds levels at which messages are journaled in addition to the
+// level passed to NewReplayLogger.
+func WithReplayLevels(levels ...LogLevel) ReplayLoggerConfigFunc {
+	return func(o *replayLoggerOptions) { o.levels = append(o.levels, levels...) }
+}
+
+// WithReplayDestination sets the logger to which journaled messages are
+// replayed. By default, messages are replayed through the wrapped logger.
+func WithReplayDestination(logger Logger) ReplayLoggerConfigFunc {
+	return func(o *replayLoggerOptions) { o.destination, o.writer = logger, nil }
+}
+
+// WithReplayWriter sets the writer to which journaled messages are replayed.
+// Messages are written with the JSON encoding regardless of the encoding of
+// the wrapped logger, but with its timestamp format, timezone, and field order.
+// Messages are timestamped with the clock set by WithReplayClock.
+func WithReplayWriter(w io.Writer) ReplayLoggerConfigFunc {
+	return func(o *replayLoggerOptions) { o.destination, o.writer = nil, w }
+}
+
+// WithReplayBundle causes journaled messages to be replayed as a single
+// message whose FieldReplayMessages field contains each journaled message
+// along with the time at which it was originally logged. The time is written
+// in the timestamp format and timezone of the replay destination. Messages
+// journaled after a replay are collected for one second, or until
+// the logger is synced, and replayed together in a single bundle. The bundle
+// is written as a JSON array by the console and logfmt encodings.
+func WithReplayBundle() ReplayLoggerConfigFunc {
+	return func(o *replayLoggerOptions) { o.bundle = true }
 }
 
 func fromReplayLogger(logger *replayLogger) ReplayLogger {
@@ -59,41 +138,108 @@ func fromReplayLogger(logger *replayLogger) ReplayLogger {
 }
 
 func newReplayLogger(logger Logger, clock glock.Clock, levels ...LogLevel) *replayLogger {
+	return newReplayLoggerWithOptions(logger, &replayLoggerOptions{clock: clock}, levels...)
+}
+
+func newReplayLoggerWithOptions(logger Logger, options *replayLoggerOptions, levels ...LogLevel) *replayLogger {
+	destination := options.destination
+	if options.writer != nil {
+		destination = newReplayWriterLogger(logger, options.writer, options.clock)
+	}
+
+	if destination == nil {
+		destination = logger
+	}
+
 	sharedJournal := &sharedJournal{
-		clock:    clock,
-		messages: []*journaledMessage{},
-		levels:   levels,
+		clock:       options.clock,
+		destination: destination,
+		bundle:      options.bundle,
+		messages:    []*journaledMessage{},
+		levels:      levels,
 	}
 
 	return &replayLogger{
 		logger:        logger,
+		destination:   destination,
+		fields:        LogFields{},
 		sharedJournal: sharedJournal,
 	}
 }
 
+// newReplayWriterLogger creates a logger that writes JSON to the given writer. The
+// logger shares the timestamp, field order, sequence, and fatal configuration of the
+// base logger backing the given logger, if any.
+func newReplayWriterLogger(logger Logger, w io.Writer, clock glock.Clock) Logger {
+	sink := newJSONLogger(nil, w)
+	options := getLoggerOptions([]LoggerConfigFunc{WithClock(clock)})
+
+	config := loggerConfig(logger)
+	if config == nil {
+		return newBaseLogger(sink, LevelDebug, nil, timestampOptions{}, fieldOrder{}, options)
+	}
+
+	if config.timestamps.format != nil {
+		sink.timeFormat = *config.timestamps.format
+	}
+
+	options.exiter = config.exiter
+	options.fatalHooks = config.fatalHooks
+	options.fatalHookTimeout = config.fatalHookTimeout
+	options.sequence = config.sequence
+	return newBaseLogger(sink, LevelDebug, nil, config.timestamps, config.order, options)
+}
+
 func (s *replayLogger) WithFields(fields LogFields) MinimalLogger {
 	if len(fields) == 0 {
 		return s
 	}
 
+	logger := s.logger.WithFields(fields)
+	destination := logger
+	if s.destination != s.logger {
+		destination = s.destination.WithFields(fields)
+	}
+
 	return &replayLogger{
-		logger:        s.logger.WithFields(fields),
+		logger:        logger,
+		destination:   destination,
+		fields:        s.fields.concat(fields),
 		sharedJournal: s.sharedJournal,
 	}
 }
 
 func (s *replayLogger) LogWithFields(level LogLevel, fields LogFields, format string, args ...interface{}) {
+	s.logSequenced(level, fields, false, format, args...)
+}
+
+func (s *replayLogger) logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
 	// Log immediately
-	s.logger.LogWithFields(level, fields, format, args...)
+	seq := logSequenced(s.logger, level, fields, journal || s.sharedJournal.shouldJournal(level), format, args...)
 
 	// Add to journal
-	s.sharedJournal.record(s.logger, level, fields, format, args)
+	s.sharedJournal.record(s.destination, s.fields, level, fields, seq, format, args)
+	return seq
+}
+
+func (s *replayLogger) config() *baseWrapper {
+	return loggerConfig(s.logger)
+}
+
+func (s *replayLogger) formatTimestamp(t time.Time) interface{} {
+	return formatTimestamp(s.logger, t)
 }
 
 func (s *replayLogger) Sync() error {
+	s.sharedJournal.flush()
 	return s.logger.Sync()
 }
 
+func (s *replayLogger) Close() error {
+	s.sharedJournal.flush()
+	return Close(s.logger)
+}
+
 func (s *replayLogger) Replay(level LogLevel) {
 	s.sharedJournal.replay(level)
 }
@@ -101,30 +247,58 @@ func (s *replayLogger) Replay(level LogLevel) {
 //
 // Shared Journal
 
-func (j *sharedJournal) record(logger Logger, level LogLevel, fields LogFields, format string, args []interface{}) {
+func (j *sharedJournal) record(logger Logger, loggerFields LogFields, level LogLevel, fields LogFields, seq uint64, format string, args []interface{}) {
 	if !j.shouldJournal(level) {
 		return
 	}
 
 	innerMessage := logMessage{
-		level:  level,
-		fields: fields.clone(),
-		format: format,
-		args:   args,
+		timestamp: j.clock.Now().UTC(),
+		sequence:  seq,
+		level:     level,
+		fields:    fields.clone(),
+		format:    format,
+		args:      args,
 	}
 
 	message := &journaledMessage{
 		logger:  logger,
+		fields:  loggerFields,
 		message: innerMessage,
 	}
 
-	j.mutex.RLock()
-	message.replay(j.replayingAt)
-	j.mutex.RUnlock()
-
 	j.mutex.Lock()
 	j.messages = append(j.messages, message)
+	replayingAt := j.replayingAt
+
+	if replayingAt != nil && j.bundle {
+		// Collect messages journaled after a replay into a single bundle
+		j.pending = append(j.pending, message)
+
+		if len(j.pending) == 1 {
+			ch := j.clock.After(replayBundleInterval)
+
+			go func() {
+				<-ch
+				j.flush()
+			}()
+		}
+	}
 	j.mutex.Unlock()
+
+	if replayingAt != nil && !j.bundle {
+		message.replay(replayingAt)
+	}
+}
+
+// flush replays the bundle of messages journaled since the last replay or flush.
+func (j *sharedJournal) flush() {
+	j.mutex.Lock()
+	pending, level := j.pending, j.replayingAt
+	j.pending = nil
+	j.mutex.Unlock()
+
+	j.replayMessages(level, pending)
 }
 
 func (j *sharedJournal) shouldJournal(level LogLevel) bool {
@@ -139,7 +313,7 @@ func (j *sharedJournal) shouldJournal(level LogLevel) bool {
 
 func (j *sharedJournal) replay(level LogLevel) {
 	j.mutex.RLock()
-	shouldReplay := j.replayingAt == nil || level < *j.replayingAt
+	shouldReplay := j.replayingAt == nil || level.rank() < j.replayingAt.rank()
 	j.mutex.RUnlock()
 
 	if !shouldReplay {
@@ -148,14 +322,39 @@ func (j *sharedJournal) replay(level LogLevel) {
 
 	j.mutex.Lock()
 	j.replayingAt = &level
+	j.pending = nil
 	j.mutex.Unlock()
 
 	j.mutex.RLock()
 	defer j.mutex.RUnlock()
 
-	for _, message := range j.messages {
-		message.replay(&level)
+	j.replayMessages(&level, j.messages)
+}
+
+func (j *sharedJournal) replayMessages(level *LogLevel, messages []*journaledMessage) {
+	if level == nil || len(messages) == 0 {
+		return
+	}
+
+	if !j.bundle {
+		for _, message := range messages {
+			message.replay(level)
+		}
+
+		return
+	}
+
+	bundle := make([]LogFields, 0, len(messages))
+	for _, message := range messages {
+		bundle = append(bundle, message.bundled())
 	}
+
+	j.destination.LogWithFields(
+		*level,
+		LogFields{FieldReplayMessages: bundle},
+		"replaying %d journaled messages",
+		len(messages),
+	)
 }
 
 func (m *journaledMessage) replay(level *LogLevel) {
@@ -163,20 +362,61 @@ func (m *journaledMessage) replay(level *LogLevel) {
 		return
 	}
 
-	// Set replay field on message
-	m.message.fields[FieldReplay] = m.message.level
+	// Set replay fields on a copy of the message
+	fields := m.message.originalFields()
+	fields[FieldReplay] = m.message.level
+	fields[FieldReplayTime] = m.message.timestamp
 
 	m.logger.LogWithFields(
 		*level,
-		m.message.fields,
+		fields,
 		m.message.format,
 		m.message.args...,
 	)
 }
 
+func (m *journaledMessage) bundled() LogFields {
+	bundled := LogFields{
+		"timestamp": formatTimestamp(m.logger, m.message.timestamp),
+		"level":     m.message.level.String(),
+		"message":   fmt.Sprintf(m.message.format, m.message.args...),
+		"fields":    m.fields.concat(m.message.fields),
+	}
+
+	if m.message.sequence != 0 {
+		bundled[FieldOriginalSequence] = m.message.sequence
+	}
+
+	return bundled
+}
+
 //
 // Adapter
 
+func (a *replayLoggerAdapter) WithFields(fields LogFields) Logger {
+	if len(fields) == 0 {
+		return a
+	}
+
+	return &replayLoggerAdapter{a.Logger.WithFields(fields), a.replayLogger}
+}
+
 func (a *replayLoggerAdapter) Replay(level LogLevel) {
 	a.replayLogger.Replay(level)
 }
+
+func (a *replayLoggerAdapter) logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
+	return logSequenced(a.Logger, level, fields, journal, format, args...)
+}
+
+func (a *replayLoggerAdapter) config() *baseWrapper {
+	return loggerConfig(a.Logger)
+}
+
+func (a *replayLoggerAdapter) formatTimestamp(t time.Time) interface{} {
+	return formatTimestamp(a.Logger, t)
+}
+
+func (a *replayLoggerAdapter) Close() error {
+	return Close(a.Logger)
+}
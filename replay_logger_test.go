@@ -1,7 +1,11 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/derision-test/glock"
 	"github.com/stretchr/testify/assert"
@@ -200,3 +204,238 @@ func TestReplayLoggerCheckSecondReplayAddsAttribute(t *testing.T) {
 	assert.NotContains(t, messages[6].fields, FieldReplay)
 	assert.Equal(t, LevelDebug, messages[7].fields[FieldReplay])
 }
+
+func TestReplayLoggerDestination(t *testing.T) {
+	logger := &testLogger{}
+	destination := &testLogger{}
+	clock := glock.NewMockClock()
+	options := &replayLoggerOptions{clock: clock, destination: FromMinimalLogger(destination)}
+	replayLogger := newReplayLoggerWithOptions(FromMinimalLogger(logger), options, LevelDebug)
+
+	replayLogger.LogWithFields(LevelDebug, nil, "foo")
+	replayLogger.LogWithFields(LevelDebug, nil, "bar")
+	replayLogger.Replay(LevelWarning)
+	replayLogger.LogWithFields(LevelDebug, nil, "baz")
+
+	messages := logger.copy()
+	require.Len(t, messages, 3)
+	for i, format := range []string{"foo", "bar", "baz"} {
+		assert.Equal(t, LevelDebug, messages[i].level)
+		assert.Equal(t, format, messages[i].format)
+	}
+
+	replayed := destination.copy()
+	require.Len(t, replayed, 3)
+	for i, format := range []string{"foo", "bar", "baz"} {
+		assert.Equal(t, LevelWarning, replayed[i].level)
+		assert.Equal(t, format, replayed[i].format)
+		assert.Equal(t, LevelDebug, replayed[i].fields[FieldReplay])
+	}
+}
+
+func TestReplayLoggerWriter(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := &testLogger{}
+	replayLogger := NewReplayLogger(FromMinimalLogger(logger), LevelDebug, WithReplayWriter(buffer))
+
+	replayLogger.WithFields(LogFields{"x": "x"}).Debug("foo %d", 12)
+	assert.Empty(t, buffer.String())
+	replayLogger.Replay(LevelError)
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 1)
+
+	data := LogFields{}
+	require.Nil(t, json.Unmarshal([]byte(lines[0]), &data))
+	assert.Equal(t, "foo 12", data["message"])
+	assert.Equal(t, "error", data["level"])
+	assert.Equal(t, "x", data["x"])
+}
+
+func TestReplayLoggerBundle(t *testing.T) {
+	logger := &testLogger{}
+	destination := &testLogger{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	options := &replayLoggerOptions{clock: clock, destination: FromMinimalLogger(destination), bundle: true}
+	replayLogger := newReplayLoggerWithOptions(FromMinimalLogger(logger), options, LevelDebug, LevelInfo)
+
+	replayLogger.WithFields(LogFields{"x": "x"}).LogWithFields(LevelDebug, LogFields{"y": "y"}, "foo %d", 12)
+	clock.Advance(time.Second)
+	replayLogger.LogWithFields(LevelInfo, nil, "bar %d", 43)
+	replayLogger.Replay(LevelError)
+	clock.Advance(time.Second)
+	replayLogger.LogWithFields(LevelDebug, nil, "baz %d", 74)
+	replayLogger.LogWithFields(LevelInfo, nil, "qux %d", 33)
+
+	// Messages journaled after the replay are bundled once the logger is synced
+	require.Len(t, destination.copy(), 1)
+	require.Nil(t, replayLogger.Sync())
+
+	replayed := destination.copy()
+	require.Len(t, replayed, 2)
+	assert.Equal(t, LevelError, replayed[0].level)
+	assert.Equal(t, LevelError, replayed[1].level)
+
+	bundle := replayed[0].fields[FieldReplayMessages].([]LogFields)
+	require.Len(t, bundle, 2)
+	assert.Equal(t, "foo 12", bundle[0]["message"])
+	assert.Equal(t, "debug", bundle[0]["level"])
+	assert.Equal(t, time.Unix(1503939881, 0).UTC().Format(JSONTimeFormat), bundle[0]["timestamp"])
+	assert.Equal(t, "x", bundle[0]["fields"].(LogFields)["x"])
+	assert.Equal(t, "y", bundle[0]["fields"].(LogFields)["y"])
+	assert.Equal(t, "bar 43", bundle[1]["message"])
+	assert.Equal(t, "info", bundle[1]["level"])
+	assert.Equal(t, time.Unix(1503939882, 0).UTC().Format(JSONTimeFormat), bundle[1]["timestamp"])
+
+	bundle = replayed[1].fields[FieldReplayMessages].([]LogFields)
+	require.Len(t, bundle, 2)
+	assert.Equal(t, "baz 74", bundle[0]["message"])
+	assert.Equal(t, time.Unix(1503939883, 0).UTC().Format(JSONTimeFormat), bundle[0]["timestamp"])
+	assert.Equal(t, "qux 33", bundle[1]["message"])
+}
+
+func TestReplayLoggerBundleInterval(t *testing.T) {
+	destination := &testLogger{}
+	clock := glock.NewMockClock()
+	options := &replayLoggerOptions{clock: clock, destination: FromMinimalLogger(destination), bundle: true}
+	replayLogger := newReplayLoggerWithOptions(FromMinimalLogger(&testLogger{}), options, LevelDebug)
+
+	replayLogger.Replay(LevelError)
+	replayLogger.LogWithFields(LevelDebug, nil, "foo")
+	replayLogger.LogWithFields(LevelDebug, nil, "bar")
+	assert.Empty(t, destination.copy())
+
+	clock.BlockingAdvance(replayBundleInterval)
+	requireEventually(t, func() bool { return len(destination.copy()) == 1 })
+
+	bundle := destination.copy()[0].fields[FieldReplayMessages].([]LogFields)
+	require.Len(t, bundle, 2)
+	assert.Equal(t, "foo", bundle[0]["message"])
+	assert.Equal(t, "bar", bundle[1]["message"])
+}
+
+func TestReplayLoggerWriterUsesLoggerConfig(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.Nil(t, err)
+
+	buffer := &bytes.Buffer{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	format := newTimestampFormat("rfc3339", JSONTimeFormat)
+	timestamps := timestampOptions{location: location, format: &format}
+	logger := newBaseLogger(NewMockLogSink(), LevelInfo, nil, timestamps, fieldOrder{}, getLoggerOptions(nil))
+	replayLogger := NewReplayLogger(logger, LevelDebug, WithReplayWriter(buffer), WithReplayClock(clock))
+
+	replayLogger.Debug("foo")
+	replayLogger.Replay(LevelError)
+
+	data := LogFields{}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &data))
+	assert.Equal(t, "2017-08-28T13:04:41-04:00", data["timestamp"])
+}
+
+func TestReplayLoggerBundleConsole(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger, err := InitLogger(&Config{LogLevel: "info", LogEncoding: "console", LogDisplayFields: true}, WithOutput(buffer))
+	require.Nil(t, err)
+
+	replayLogger := NewReplayLogger(logger, LevelDebug, WithReplayBundle())
+	replayLogger.Debug("foo")
+	replayLogger.Replay(LevelError)
+
+	assert.Contains(t, buffer.String(), `replayed-messages=[{"fields":{"caller":`)
+	assert.Contains(t, buffer.String(), `"level":"debug","message":"foo","original-sequence":1,`)
+}
+
+func TestReplayLoggerBundleTimestampFormat(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	require.Nil(t, err)
+
+	for _, testCase := range []struct {
+		format   string
+		expected interface{}
+	}{
+		{"", "2017-08-28T13:04:41.000-0400"},
+		{"rfc3339", "2017-08-28T13:04:41-04:00"},
+		{"unixmilli", int64(1503939881000)},
+	} {
+		sink := NewMockLogSink()
+		clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+		format := newTimestampFormat(testCase.format, JSONTimeFormat)
+		timestamps := timestampOptions{location: location, format: &format}
+		logger := newBaseLogger(sink, LevelInfo, nil, timestamps, fieldOrder{}, getLoggerOptions([]LoggerConfigFunc{WithClock(clock)}))
+		options := &replayLoggerOptions{clock: clock, bundle: true}
+		replayLogger := fromReplayLogger(newReplayLoggerWithOptions(logger, options, LevelDebug))
+
+		replayLogger.Debug("foo")
+		replayLogger.Replay(LevelError)
+
+		history := sink.LogFunc.History()
+		require.Len(t, history, 1)
+
+		bundle := history[0].Arg2[FieldReplayMessages].([]LogFields)
+		require.Len(t, bundle, 1)
+		assert.Equal(t, testCase.expected, bundle[0]["timestamp"])
+	}
+}
+
+func TestReplayLoggerPreservesTimestampAndSequence(t *testing.T) {
+	sink := NewMockLogSink()
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	logger := newTestLogger(sink, LevelDebug, nil, clock, func() {})
+	replayLogger := fromReplayLogger(newReplayLogger(logger, clock, LevelDebug))
+
+	replayLogger.Debug("foo")
+	clock.Advance(time.Second)
+	replayLogger.Debug("bar")
+	clock.Advance(time.Second)
+	replayLogger.Replay(LevelError)
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 4)
+
+	for i, call := range history[2:] {
+		assert.Equal(t, LevelError, call.Arg1)
+		assert.Equal(t, time.Unix(1503939883, 0).UTC(), call.Arg0)
+		assert.Equal(t, uint64(i+1), call.Arg2[FieldOriginalSequence])
+		assert.Equal(t, uint64(i+3), call.Arg2["sequenceNumber"])
+		assert.Equal(t, time.Unix(int64(1503939881+i), 0).UTC().Format(JSONTimeFormat), call.Arg2[FieldReplayTime])
+	}
+}
+
+func TestReplayLoggerSequenceBelowLevel(t *testing.T) {
+	sink := NewMockLogSink()
+	clock := glock.NewMockClock()
+	logger := newTestLogger(sink, LevelInfo, nil, clock, func() {})
+	replayLogger := fromReplayLogger(newReplayLogger(logger, clock, LevelDebug))
+
+	fields := LogFields{"a": 1}
+	replayLogger.DebugWithFields(fields, "foo")
+	replayLogger.DebugWithFields(fields, "bar")
+	assert.NotContains(t, fields, "sequenceNumber")
+
+	replayLogger.Replay(LevelInfo)
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 2)
+
+	for i, call := range history {
+		assert.Equal(t, uint64(i+1), call.Arg2[FieldOriginalSequence])
+		assert.Equal(t, uint64(i+3), call.Arg2["sequenceNumber"])
+	}
+}
+
+func TestReplayLoggerOriginalTimestamps(t *testing.T) {
+	sink := NewMockLogSink()
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	logger := newBaseLogger(sink, LevelDebug, nil, timestampOptions{useOriginal: true}, fieldOrder{}, getLoggerOptions([]LoggerConfigFunc{WithClock(clock)}))
+	replayLogger := fromReplayLogger(newReplayLogger(logger, clock, LevelDebug))
+
+	replayLogger.Debug("foo")
+	clock.Advance(time.Minute)
+	replayLogger.Replay(LevelError)
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, time.Unix(1503939881, 0).UTC(), history[0].Arg0)
+	assert.Equal(t, time.Unix(1503939881, 0).UTC(), history[1].Arg0)
+}
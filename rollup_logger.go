@@ -12,7 +12,19 @@ import (
 // window before it was flushed.
 const FieldRollup = "rollup-multiplicity"
 
+// FieldRollupTime is a field assigned to the last message in a
+// window. Its value is equal to the time at which the first message
+// in the window was logged.
+const FieldRollupTime = "rollup-from-time"
+
 type (
+	// RollupLoggerConfigFunc is a function used to configure a rollup logger.
+	RollupLoggerConfigFunc func(*rollupLoggerOptions)
+
+	rollupLoggerOptions struct {
+		clock glock.Clock
+	}
+
 	rollupLogger struct {
 		logger         Logger
 		clock          glock.Clock
@@ -36,8 +48,18 @@ var _ MinimalLogger = &rollupLogger{}
 // seen in the same window period. All remaining messages logged within that period
 // are captured and emitted as a single message at the end of the window period. The
 // fields and args are equal to the first rolled-up message.
-func NewRollupLogger(logger Logger, windowDuration time.Duration) Logger {
-	return FromMinimalLogger(newRollupLogger(logger, glock.NewRealClock(), windowDuration))
+func NewRollupLogger(logger Logger, windowDuration time.Duration, configs ...RollupLoggerConfigFunc) Logger {
+	options := &rollupLoggerOptions{clock: glock.NewRealClock()}
+	for _, f := range configs {
+		f(options)
+	}
+
+	return FromMinimalLogger(newRollupLogger(logger, options.clock, windowDuration))
+}
+
+// WithRollupClock sets the clock used to determine the bounds of each window.
+func WithRollupClock(clock glock.Clock) RollupLoggerConfigFunc {
+	return func(o *rollupLoggerOptions) { o.clock = clock }
 }
 
 func newRollupLogger(logger Logger, clock glock.Clock, windowDuration time.Duration) *rollupLogger {
@@ -62,10 +84,11 @@ func (s *rollupLogger) WithFields(fields LogFields) MinimalLogger {
 }
 
 func (s *rollupLogger) LogWithFields(level LogLevel, fields LogFields, format string, args ...interface{}) {
-	if s.getWindow(format).record(s.logger, s.clock, s.windowDuration, level, fields, format, args...) {
-		// Not rolling up, log immediately
-		s.logger.LogWithFields(level, fields, format, args...)
-	}
+	s.logSequenced(level, fields, false, format, args...)
+}
+
+func (s *rollupLogger) logSequenced(level LogLevel, fields LogFields, journal bool, format string, args ...interface{}) uint64 {
+	return s.getWindow(format).record(s.logger, s.clock, s.windowDuration, level, fields, format, args...)
 }
 
 func (s *rollupLogger) getWindow(format string) *logWindow {
@@ -88,6 +111,14 @@ func (s *rollupLogger) getWindow(format string) *logWindow {
 	return window
 }
 
+func (s *rollupLogger) config() *baseWrapper {
+	return loggerConfig(s.logger)
+}
+
+func (s *rollupLogger) formatTimestamp(t time.Time) interface{} {
+	return formatTimestamp(s.logger, t)
+}
+
 func (s *rollupLogger) Sync() error {
 	for _, window := range s.windows {
 		window.flush(s.logger)
@@ -96,9 +127,20 @@ func (s *rollupLogger) Sync() error {
 	return s.logger.Sync()
 }
 
+func (s *rollupLogger) Close() error {
+	for _, window := range s.windows {
+		window.flush(s.logger)
+	}
+
+	return Close(s.logger)
+}
+
 //
 // Log Window
 
+// record logs the message immediately if it starts a new window and returns the
+// sequence number assigned to it. Otherwise, the message is counted towards the
+// current window and zero is returned.
 func (w *logWindow) record(
 	logger Logger,
 	clock glock.Clock,
@@ -107,7 +149,7 @@ func (w *logWindow) record(
 	fields LogFields,
 	format string,
 	args ...interface{},
-) bool {
+) uint64 {
 	if fields == nil {
 		fields = LogFields{}
 	}
@@ -129,21 +171,27 @@ func (w *logWindow) record(
 			}()
 		}
 
-		return false
+		return 0
 	}
 
 	w.flushLocked(logger)
 
+	// Not rolling up, log immediately. The message is stashed in case it is
+	// rolled up later, so it is assigned a sequence number even if filtered out
+	seq := logSequenced(logger, level, fields, true, format, args...)
+
 	w.count = 0
 	w.start = now
 	w.stashed = &logMessage{
-		level:  level,
-		fields: fields,
-		format: format,
-		args:   args,
+		timestamp: now.UTC(),
+		sequence:  seq,
+		level:     level,
+		fields:    fields.clone(),
+		format:    format,
+		args:      args,
 	}
 
-	return true
+	return seq
 }
 
 func (w *logWindow) flush(logger Logger) {
@@ -157,12 +205,14 @@ func (w *logWindow) flushLocked(logger Logger) {
 		return
 	}
 
-	// Set replay field on message
-	w.stashed.fields[FieldRollup] = w.count
+	// Set rollup fields on a copy of the message
+	fields := w.stashed.originalFields()
+	fields[FieldRollup] = w.count
+	fields[FieldRollupTime] = w.stashed.timestamp
 
 	logger.LogWithFields(
 		w.stashed.level,
-		w.stashed.fields,
+		fields,
 		w.stashed.format,
 		w.stashed.args...,
 	)
@@ -74,3 +74,22 @@ func TestRollupLoggerAllDistinctMessages(t *testing.T) {
 
 	assert.Len(t, logger.copy(), 3)
 }
+
+func TestRollupLoggerPreservesTimestampAndSequence(t *testing.T) {
+	sink := NewMockLogSink()
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	logger := newTestLogger(sink, LevelDebug, nil, clock, func() {})
+	rollupLogger := FromMinimalLogger(newRollupLogger(logger, clock, time.Second))
+
+	rollupLogger.Debug("a")
+	rollupLogger.Debug("a")
+	rollupLogger.Debug("a")
+	clock.BlockingAdvance(time.Second)
+	requireEventually(t, func() bool { return len(sink.LogFunc.History()) == 2 })
+
+	fields := sink.LogFunc.History()[1].Arg2
+	assert.Equal(t, 2, fields[FieldRollup])
+	assert.Equal(t, uint64(1), fields[FieldOriginalSequence])
+	assert.Equal(t, uint64(2), fields["sequenceNumber"])
+	assert.Equal(t, time.Unix(1503939881, 0).UTC().Format(JSONTimeFormat), fields[FieldRollupTime])
+}
@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+	"strings"
+	"sync"
+)
+
+// FieldSource is a field assigned to each message emitted by a Writer. Its value
+// is equal to the source name given when the Writer was created.
+const FieldSource = "source"
+
+// Writer is an io.Writer that emits each line written to it as a message on the
+// wrapped logger. Partial lines are buffered until a newline is written or until
+// the writer is synced.
+type Writer struct {
+	logger Logger
+	level  LogLevel
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+// NewWriter creates a Writer that logs each line written to it at the given level.
+// Each message carries a FieldSource field with the given source name.
+func NewWriter(logger Logger, level LogLevel, source string) *Writer {
+	return &Writer{
+		logger: logger.WithFields(LogFields{FieldSource: source}),
+		level:  level,
+	}
+}
+
+// NewStdLogger creates a standard library logger that logs each line printed to it
+// at the given level. Each message carries a FieldSource field with the given source
+// name. The returned logger does not add its own timestamp or prefix.
+func NewStdLogger(logger Logger, level LogLevel, source string) *stdlog.Logger {
+	return stdlog.New(NewWriter(logger, level, source), "", 0)
+}
+
+// RedirectStdLog reroutes the output of the standard library's global logger through
+// the given logger at the info level. The returned function restores the previous
+// output, flags, and prefix of the global logger.
+func RedirectStdLog(logger Logger) func() {
+	flags := stdlog.Flags()
+	prefix := stdlog.Prefix()
+	writer := stdlog.Writer()
+
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+	stdlog.SetOutput(NewWriter(logger, LevelInfo, "stdlog"))
+
+	return func() {
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+		stdlog.SetOutput(writer)
+	}
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.buffer.Write(p)
+
+	for {
+		idx := bytes.IndexByte(w.buffer.Bytes(), '\n')
+		if idx < 0 {
+			break
+		}
+
+		line := string(w.buffer.Next(idx + 1))
+		w.emit(line[:idx])
+	}
+
+	return len(p), nil
+}
+
+// Sync logs any buffered partial line and syncs the wrapped logger.
+func (w *Writer) Sync() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.buffer.Len() > 0 {
+		w.emit(w.buffer.String())
+		w.buffer.Reset()
+	}
+
+	return w.logger.Sync()
+}
+
+// Close is an alias of Sync.
+func (w *Writer) Close() error {
+	return w.Sync()
+}
+
+func (w *Writer) emit(line string) {
+	line = strings.TrimSuffix(line, "\r")
+	if line == "" {
+		return
+	}
+
+	w.logger.LogWithFields(w.level, LogFields{"caller": getExternalCaller()}, "%s", line)
+}
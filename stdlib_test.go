@@ -0,0 +1,95 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	stdlog "log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriterSplitsLines(t *testing.T) {
+	logger := &testLogger{}
+	writer := NewWriter(FromMinimalLogger(logger), LevelWarning, "test")
+
+	fmt.Fprint(writer, "foo\nbar\r\n\nba")
+	require.Len(t, logger.copy(), 2)
+
+	fmt.Fprint(writer, "z\nbo")
+	require.Len(t, logger.copy(), 3)
+
+	writer.Sync()
+	messages := logger.copy()
+	require.Len(t, messages, 4)
+
+	for i, line := range []string{"foo", "bar", "baz", "bo"} {
+		assert.Equal(t, LevelWarning, messages[i].level)
+		assert.Equal(t, line, fmt.Sprintf(messages[i].format, messages[i].args...))
+	}
+}
+
+func TestStdLogger(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+	stdLogger := NewStdLogger(logger, LevelError, "dependency")
+	stdLogger.Printf("foo %d", 12)
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelError, history[0].Arg1)
+	assert.Equal(t, "foo 12", history[0].Arg3)
+	assert.Equal(t, "dependency", history[0].Arg2[FieldSource])
+
+	// Note: this value refers to the line number containing `stdLogger.Printf` in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "log/stdlib_test.go:42", history[0].Arg2["caller"])
+}
+
+func TestRedirectStdLog(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+
+	output := &bytes.Buffer{}
+	stdlog.SetOutput(output)
+	defer stdlog.SetOutput(os.Stderr)
+
+	restore := RedirectStdLog(logger)
+	stdlog.Println("foo")
+	restore()
+	stdlog.Println("bar")
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 1)
+	assert.Equal(t, LevelInfo, history[0].Arg1)
+	assert.Equal(t, "foo", history[0].Arg3)
+	assert.Equal(t, "stdlog", history[0].Arg2[FieldSource])
+	assert.Contains(t, output.String(), "bar")
+}
+
+func TestWriterCallerSkipsStdlib(t *testing.T) {
+	sink := NewMockLogSink()
+	logger := newTestLogger(sink, LevelDebug, nil, glock.NewMockClock(), func() {})
+	writer := NewWriter(logger, LevelInfo, "test")
+
+	_, file, line, _ := runtime.Caller(0)
+	fmt.Fprintf(writer, "foo %d\n", 12)
+
+	buffered := bufio.NewWriter(writer)
+	buffered.WriteString("bar\n")
+	buffered.Flush()
+
+	history := sink.LogFunc.History()
+	require.Len(t, history, 2)
+	assert.Equal(t, fmt.Sprintf("log/%s:%d", filepath.Base(file), line+1), history[0].Arg2["caller"])
+	assert.Equal(t, fmt.Sprintf("log/%s:%d", filepath.Base(file), line+5), history[1].Arg2["caller"])
+	assert.True(t, isStdlibFrame(stdlibSourceDir+"fmt/print.go"))
+	assert.False(t, isStdlibFrame(file))
+}
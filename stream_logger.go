@@ -0,0 +1,340 @@
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/derision-test/glock"
+)
+
+const (
+	// DefaultOutputFlushTimeout is the maximum duration for which Sync waits for
+	// buffered messages to be written to a network output.
+	DefaultOutputFlushTimeout = time.Second * 5
+
+	streamBufferSize     = 1024
+	streamWriteTimeout   = time.Second * 10
+	streamInitialBackoff = time.Millisecond * 100
+	streamMaxBackoff     = time.Second * 30
+)
+
+var ErrOutputFlushTimeout = fmt.Errorf("timed out flushing log output")
+
+// streamLogger decorates a sink whose encoded output is written to a streamWriter
+// so that syncing the logger flushes buffered messages to the network.
+type streamLogger struct {
+	logSink
+	writer *streamWriter
+}
+
+func newStreamLogger(sink logSink, writer *streamWriter, clock glock.Clock) *streamLogger {
+	writer.onDrop = reportDrops(sink, clock)
+	return &streamLogger{logSink: sink, writer: writer}
+}
+
+// reportDrops returns a callback that writes the number of messages dropped while
+// the output was unavailable to the given sink.
+func reportDrops(sink logSink, clock glock.Clock) func(dropped int) {
+	return func(dropped int) {
+		sink.Log(clock.Now(), LevelWarning, LogFields{"dropped": dropped}, "dropped log messages while output was unavailable")
+	}
+}
+
+func (l *streamLogger) Sync() error {
+	return l.writer.Sync()
+}
+
+func (l *streamLogger) Close() error {
+	return l.writer.Close()
+}
+
+func (l *streamLogger) LogOrdered(timestamp time.Time, level LogLevel, fields LogFields, keys []string, msg string) error {
+	if ordered, ok := l.logSink.(orderedSink); ok {
+		return ordered.LogOrdered(timestamp, level, fields, keys, msg)
+	}
+
+	return l.logSink.Log(timestamp, level, fields, msg)
+}
+
+func (l *streamLogger) encodesNativeTimes() bool {
+	return encodesNativeTimes(l.logSink)
+}
+
+// streamWriter writes messages to a TCP, unix, or TLS connection.
+// Messages are buffered while the connection is being (re-)established. When the
+// buffer is full, new messages are dropped and the number of dropped messages is
+// reported once the connection recovers.
+type streamWriter struct {
+	dial         func() (net.Conn, error)
+	clock        glock.Clock
+	maxSize      int
+	flushTimeout time.Duration
+	onDrop       func(dropped int)
+	closeOnce    sync.Once
+	mutex        sync.Mutex
+	queue        []streamLine
+	position     uint64
+	written      uint64
+	dropped      int
+	waiters      []streamWaiter
+	signal       chan struct{}
+	done         chan struct{}
+	stopped      chan struct{}
+}
+
+// streamLine is a buffered message along with its position in the order of
+// messages accepted by the writer.
+type streamLine struct {
+	position uint64
+	data     []byte
+}
+
+// streamWaiter is a goroutine blocked in Sync until all messages up to the given
+// position have been written.
+type streamWaiter struct {
+	position uint64
+	ch       chan struct{}
+}
+
+func newStreamWriter(dial func() (net.Conn, error), maxSize int, clock glock.Clock) *streamWriter {
+	if maxSize <= 0 {
+		maxSize = streamBufferSize
+	}
+
+	w := &streamWriter{
+		dial:         dial,
+		clock:        clock,
+		maxSize:      maxSize,
+		flushTimeout: DefaultOutputFlushTimeout,
+		signal:       make(chan struct{}, 1),
+		done:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	go w.run()
+	return w
+}
+
+// newStreamDialer returns a function that connects to the given tcp://, unix://, or
+// tls:// address. TLS connections are configured by the LogOutputTLS* options.
+func newStreamDialer(address string, c *Config) (func() (net.Conn, error), error) {
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, ErrIllegalOutputAddress
+	}
+
+	dialer := &net.Dialer{Timeout: streamWriteTimeout}
+
+	switch u.Scheme {
+	case "tcp":
+		return func() (net.Conn, error) { return dialer.Dial("tcp", u.Host) }, nil
+
+	case "unix":
+		return func() (net.Conn, error) { return dialer.Dial("unix", u.Path) }, nil
+
+	case "tls":
+		config := &tls.Config{
+			ServerName:         u.Hostname(),
+			InsecureSkipVerify: c.LogOutputTLSInsecureSkipVerify,
+		}
+
+		if c.LogOutputTLSCAFile != "" {
+			contents, err := os.ReadFile(c.LogOutputTLSCAFile)
+			if err != nil {
+				return nil, err
+			}
+
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(contents) {
+				return nil, fmt.Errorf("no certificates found in %s", c.LogOutputTLSCAFile)
+			}
+
+			config.RootCAs = pool
+		}
+
+		return func() (net.Conn, error) { return tls.DialWithDialer(dialer, "tcp", u.Host, config) }, nil
+	}
+
+	return nil, ErrIllegalOutputAddress
+}
+
+// Write enqueues a copy of the given message. Write never blocks on the network.
+func (w *streamWriter) Write(p []byte) (int, error) {
+	w.mutex.Lock()
+	if len(w.queue) < w.maxSize {
+		w.position++
+		w.queue = append(w.queue, streamLine{position: w.position, data: append([]byte(nil), p...)})
+	} else {
+		w.dropped++
+	}
+	w.mutex.Unlock()
+
+	notify(w.signal)
+	return len(p), nil
+}
+
+// Sync blocks until the messages buffered at the time of the call have been written
+// to the connection (or dropped) or the flush timeout elapses. Messages written after
+// the call do not delay its return.
+func (w *streamWriter) Sync() error {
+	w.mutex.Lock()
+	if w.written >= w.position {
+		w.mutex.Unlock()
+		return nil
+	}
+
+	ch := make(chan struct{})
+	w.waiters = append(w.waiters, streamWaiter{position: w.position, ch: ch})
+	w.mutex.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-w.clock.After(w.flushTimeout):
+		return ErrOutputFlushTimeout
+	}
+}
+
+// Close stops the background writer and closes the connection. Messages that have
+// not yet been written are discarded.
+func (w *streamWriter) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	<-w.stopped
+	return nil
+}
+
+func (w *streamWriter) run() {
+	defer close(w.stopped)
+
+	var conn net.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	backoff := streamInitialBackoff
+
+	for {
+		lines, dropped := w.take()
+		if len(lines) == 0 {
+			select {
+			case <-w.signal:
+				continue
+			case <-w.done:
+				return
+			}
+		}
+
+		if conn == nil {
+			var err error
+			if conn, err = w.dial(); err != nil {
+				conn = nil
+				w.requeue(lines, dropped)
+
+				select {
+				case <-w.clock.After(backoff):
+				case <-w.done:
+					return
+				}
+
+				if backoff *= 2; backoff > streamMaxBackoff {
+					backoff = streamMaxBackoff
+				}
+
+				continue
+			}
+
+			backoff = streamInitialBackoff
+		}
+
+		for i, line := range lines {
+			conn.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+
+			if n, err := conn.Write(line.data); err != nil {
+				conn.Close()
+				conn = nil
+
+				// A partially written line cannot be completed on a new connection
+				// without duplicating its prefix, so it is dropped instead
+				if n > 0 {
+					i++
+					dropped++
+				}
+
+				w.requeue(lines[i:], dropped)
+				dropped = 0
+				break
+			}
+
+			w.markWritten(line.position)
+		}
+
+		if conn != nil && dropped > 0 && w.onDrop != nil {
+			w.onDrop(dropped)
+		}
+	}
+}
+
+// take removes all buffered messages from the queue along with the number of
+// messages dropped since the last call. If the queue is empty, all messages have
+// been written or dropped and any goroutines blocked in Sync are released.
+func (w *streamWriter) take() ([]streamLine, int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	lines, dropped := w.queue, w.dropped
+	w.queue, w.dropped = nil, 0
+
+	if len(lines) == 0 {
+		w.written = w.position
+		w.releaseWaiters()
+	}
+
+	return lines, dropped
+}
+
+// markWritten records that all messages up to the given position have been written
+// or dropped and releases the goroutines blocked in Sync that were waiting for them.
+func (w *streamWriter) markWritten(position uint64) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.written = position
+	w.releaseWaiters()
+}
+
+func (w *streamWriter) releaseWaiters() {
+	waiters := w.waiters[:0]
+	for _, waiter := range w.waiters {
+		if waiter.position <= w.written {
+			close(waiter.ch)
+		} else {
+			waiters = append(waiters, waiter)
+		}
+	}
+
+	w.waiters = waiters
+}
+
+// requeue places unwritten messages back at the front of the queue. Messages that
+// no longer fit into the buffer are counted as dropped.
+func (w *streamWriter) requeue(lines []streamLine, dropped int) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	queue := append(lines, w.queue...)
+	if len(queue) > w.maxSize {
+		dropped += len(queue) - w.maxSize
+		queue = queue[:w.maxSize]
+	}
+
+	w.queue = queue
+	w.dropped += dropped
+}
@@ -0,0 +1,255 @@
+package log
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLoggerTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:         "info",
+		LogEncoding:      "json",
+		LogOutputAddress: "tcp://" + listener.Addr().String(),
+	})
+	require.Nil(t, err)
+
+	logger.InfoWithFields(LogFields{"attr1": 4321}, "test 1234")
+	require.Nil(t, logger.Sync())
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	data := readStreamMessage(t, bufio.NewReader(conn))
+	assert.Equal(t, "test 1234", data["message"])
+	assert.Equal(t, float64(4321), data["attr1"])
+}
+
+func TestStreamLoggerClose(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:         "info",
+		LogEncoding:      "json",
+		LogOutputAddress: "tcp://" + listener.Addr().String(),
+	})
+	require.Nil(t, err)
+
+	logger.Info("test 1234")
+	require.Nil(t, Close(logger))
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	data := readStreamMessage(t, reader)
+	assert.Equal(t, "test 1234", data["message"])
+
+	// The connection is closed once buffered messages are written
+	_, err = reader.ReadByte()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestStreamLoggerBuffersWhileDisconnected(t *testing.T) {
+	dir, err := os.MkdirTemp("", "stream")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+	address := filepath.Join(dir, "socket")
+
+	clock := glock.NewMockClock()
+	logger, err := InitLogger(&Config{
+		LogLevel:            "info",
+		LogEncoding:         "logfmt",
+		LogOutputAddress:    "unix://" + address,
+		LogOutputBufferSize: 2,
+	}, WithClock(clock))
+	require.Nil(t, err)
+
+	// Wait for the second failed connection attempt
+	logger.Info("A")
+	clock.BlockingAdvance(streamInitialBackoff)
+	requireEventually(t, func() bool { return clock.BlockedOnAfter() == 1 })
+
+	logger.Info("B")
+	logger.Info("C")
+	logger.Info("D")
+
+	listener, err := net.Listen("unix", address)
+	require.Nil(t, err)
+	defer listener.Close()
+
+	clock.BlockingAdvance(streamInitialBackoff * 2)
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for _, expected := range []string{"message=A", "message=B", "message=\"dropped log messages while output was unavailable\" dropped=2"} {
+		line, err := reader.ReadString('\n')
+		require.Nil(t, err)
+		assert.Contains(t, line, expected)
+	}
+}
+
+func TestStreamLoggerTLS(t *testing.T) {
+	server := httptest.NewUnstartedServer(nil)
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	contents := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.Nil(t, os.WriteFile(caFile, contents, 0o600))
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", server.TLS)
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := InitLogger(&Config{
+		LogLevel:           "info",
+		LogEncoding:        "json",
+		LogOutputAddress:   "tls://" + listener.Addr().String(),
+		LogOutputTLSCAFile: caFile,
+	})
+	require.Nil(t, err)
+
+	logger.Info("test 1234")
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	data := readStreamMessage(t, bufio.NewReader(conn))
+	assert.Equal(t, "test 1234", data["message"])
+}
+
+func TestStreamLoggerSyncTimeout(t *testing.T) {
+	dial, err := newStreamDialer("unix://"+filepath.Join(t.TempDir(), "missing"), &Config{})
+	require.Nil(t, err)
+
+	writer := newStreamWriter(dial, 1, glock.NewRealClock())
+	defer writer.Close()
+
+	writer.flushTimeout = time.Millisecond * 10
+	writer.Write([]byte("test\n"))
+	assert.Equal(t, ErrOutputFlushTimeout, writer.Sync())
+}
+
+func TestStreamLoggerSyncWaitsForBufferedMessages(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	writer := newStreamWriter(func() (net.Conn, error) { return client, nil }, 0, glock.NewRealClock())
+	defer writer.Close()
+
+	writer.Write([]byte("A\n"))
+
+	errs := make(chan error, 1)
+	go func() { errs <- writer.Sync() }()
+	requireEventually(t, func() bool {
+		writer.mutex.Lock()
+		defer writer.mutex.Unlock()
+		return len(writer.waiters) == 1
+	})
+
+	// The second message is not read until Sync returns
+	writer.Write([]byte("B\n"))
+
+	reader := bufio.NewReader(server)
+	line, err := reader.ReadString('\n')
+	require.Nil(t, err)
+	assert.Equal(t, "A\n", line)
+
+	select {
+	case err := <-errs:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("sync did not return")
+	}
+
+	line, err = reader.ReadString('\n')
+	require.Nil(t, err)
+	assert.Equal(t, "B\n", line)
+}
+
+type partialWriteConn struct {
+	net.Conn
+	mutex   sync.Mutex
+	partial bool
+	written []string
+}
+
+func (c *partialWriteConn) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.partial {
+		c.partial = false
+		return 2, io.ErrShortWrite
+	}
+
+	c.written = append(c.written, string(p))
+	return len(p), nil
+}
+
+func (c *partialWriteConn) Written() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string(nil), c.written...)
+}
+
+func (c *partialWriteConn) SetWriteDeadline(t time.Time) error { return nil }
+func (c *partialWriteConn) Close() error                       { return nil }
+
+func TestStreamLoggerDropsPartiallyWrittenMessages(t *testing.T) {
+	conn := &partialWriteConn{partial: true}
+	writer := newStreamWriter(func() (net.Conn, error) { return conn, nil }, 0, glock.NewRealClock())
+	defer writer.Close()
+
+	reported := make(chan int, 1)
+	writer.onDrop = func(dropped int) { reported <- dropped }
+
+	writer.Write([]byte("A\n"))
+	writer.Write([]byte("B\n"))
+	require.Nil(t, writer.Sync())
+
+	assert.Equal(t, []string{"B\n"}, conn.Written())
+	assert.Equal(t, 1, <-reported)
+}
+
+func TestStreamLoggerIllegalAddress(t *testing.T) {
+	for _, address := range []string{"udp://localhost:514", "localhost:514", "://"} {
+		_, err := newStreamDialer(address, &Config{})
+		assert.Equal(t, ErrIllegalOutputAddress, err)
+	}
+}
+
+func readStreamMessage(t *testing.T, reader *bufio.Reader) LogFields {
+	line, err := reader.ReadString('\n')
+	require.Nil(t, err)
+
+	data := LogFields{}
+	require.Nil(t, json.Unmarshal([]byte(line), &data))
+	return data
+}
@@ -0,0 +1,282 @@
+package log
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSyslogStructuredDataID is the SD-ID of the RFC 5424 structured data element
+// that carries log fields, unless configured otherwise.
+const DefaultSyslogStructuredDataID = "fields@32473"
+
+type syslogLogger struct {
+	network  string
+	address  string
+	format   string
+	facility int
+	hostname string
+	appName  string
+	procID   string
+	sdID     string
+	conn     net.Conn
+	mutex    sync.Mutex
+}
+
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+var syslogSeverities = map[LogLevel]int{
+	LevelFatal:   2, // critical
+	LevelPanic:   2, // critical
+	LevelError:   3, // error
+	LevelWarning: 4, // warning
+	LevelInfo:    6, // informational
+	LevelDebug:   7, // debug
+}
+
+func newSyslogLogger(c *Config) (*syslogLogger, error) {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	appName := c.LogSyslogAppName
+	if appName == "" {
+		appName = filepath.Base(os.Args[0])
+	}
+
+	procID := c.LogSyslogProcID
+	if procID == "" {
+		procID = strconv.Itoa(os.Getpid())
+	}
+
+	sdID := c.LogSyslogStructuredDataID
+	if sdID == "" {
+		sdID = DefaultSyslogStructuredDataID
+	}
+
+	facilityName := strings.ToLower(c.LogSyslogFacility)
+	if facilityName == "" {
+		facilityName = "user"
+	}
+
+	facility, ok := syslogFacilities[facilityName]
+	if !ok {
+		return nil, ErrIllegalSyslogFacility
+	}
+
+	l := &syslogLogger{
+		network:  c.LogSyslogNetwork,
+		address:  c.LogSyslogAddress,
+		format:   c.LogSyslogFormat,
+		facility: facility,
+		hostname: hostname,
+		appName:  appName,
+		procID:   procID,
+		sdID:     sdID,
+	}
+
+	if err := l.connect(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+func (l *syslogLogger) connect() error {
+	if l.network != "" {
+		conn, err := net.Dial(l.network, l.address)
+		if err != nil {
+			return err
+		}
+
+		l.conn = conn
+		return nil
+	}
+
+	address := l.address
+	if address == "" {
+		address = "/dev/log"
+	}
+
+	var err error
+	for _, network := range []string{"unixgram", "unix"} {
+		var conn net.Conn
+		if conn, err = net.Dial(network, address); err == nil {
+			l.network = network
+			l.conn = conn
+			return nil
+		}
+	}
+
+	return err
+}
+
+func (l *syslogLogger) Log(timestamp time.Time, level LogLevel, fields LogFields, msg string) error {
+	var frame string
+	if l.format == "rfc3164" {
+		frame = l.formatRFC3164(timestamp, level, fields, msg)
+	} else {
+		frame = l.formatRFC5424(timestamp, level, fields, msg)
+	}
+
+	switch l.network {
+	case "tcp", "tcp4", "tcp6":
+		// Octet-counting framing as described in RFC 6587
+		frame = fmt.Sprintf("%d %s", len(frame), frame)
+	case "unix":
+		frame += "\n"
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if _, err := l.conn.Write([]byte(frame)); err != nil {
+		// Reconnect once in case the daemon has restarted
+		l.conn.Close()
+		if err := l.connect(); err != nil {
+			return err
+		}
+
+		if _, err := l.conn.Write([]byte(frame)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (l *syslogLogger) Close() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.conn.Close()
+}
+
+func (l *syslogLogger) priority(level LogLevel) int {
+	return l.facility*8 + syslogSeverity(level)
+}
+
+func syslogSeverity(level LogLevel) int {
+	if severity, ok := syslogSeverities[level]; ok {
+		return severity
+	}
+
+	return 5 // notice
+}
+
+// formatRFC5424 renders a message as described in RFC 5424. The log fields are
+// encoded as the parameters of a single structured data element.
+func (l *syslogLogger) formatRFC5424(timestamp time.Time, level LogLevel, fields LogFields, msg string) string {
+	return fmt.Sprintf(
+		"<%d>1 %s %s %s %s - %s %s",
+		l.priority(level),
+		timestamp.Format("2006-01-02T15:04:05.000000Z07:00"),
+		syslogHeaderValue(l.hostname, 255),
+		syslogHeaderValue(l.appName, 48),
+		syslogHeaderValue(l.procID, 128),
+		l.structuredData(fields),
+		msg,
+	)
+}
+
+// formatRFC3164 renders a message in the legacy BSD format described in RFC 3164.
+// The log fields are appended to the message as key=value pairs. The hostname is
+// omitted when writing to a local socket, as is conventional for local daemons.
+func (l *syslogLogger) formatRFC3164(timestamp time.Time, level LogLevel, fields LogFields, msg string) string {
+	header := fmt.Sprintf("<%d>%s", l.priority(level), timestamp.Format(time.Stamp))
+	if l.network != "unix" && l.network != "unixgram" {
+		header += " " + l.hostname
+	}
+
+	parts := []string{msg}
+	for _, key := range sortedFieldKeys(fields) {
+		parts = append(parts, fmt.Sprintf("%s=%v", key, fields[key]))
+	}
+
+	return fmt.Sprintf("%s %s[%s]: %s", header, l.appName, l.procID, strings.Join(parts, " "))
+}
+
+func (l *syslogLogger) structuredData(fields LogFields) string {
+	if len(fields) == 0 {
+		return "-"
+	}
+
+	parts := []string{l.sdID}
+	for _, key := range sortedFieldKeys(fields) {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, syslogParamName(key), syslogParamValue(fields[key])))
+	}
+
+	return "[" + strings.Join(parts, " ") + "]"
+}
+
+// syslogHeaderValue replaces characters disallowed in RFC 5424 header fields and
+// truncates the value to the maximum length of the field.
+func syslogHeaderValue(value string, maxLength int) string {
+	value = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return '_'
+		}
+
+		return r
+	}, value)
+
+	if value == "" {
+		return "-"
+	}
+	if len(value) > maxLength {
+		value = value[:maxLength]
+	}
+
+	return value
+}
+
+// syslogParamName replaces characters disallowed in RFC 5424 SD-PARAM names and
+// truncates the name to 32 characters.
+func syslogParamName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, name)
+
+	if len(name) > 32 {
+		name = name[:32]
+	}
+
+	return name
+}
+
+// syslogParamValue escapes the characters that must be escaped in RFC 5424
+// SD-PARAM values.
+func syslogParamValue(value interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`).Replace(textValue(value))
+}
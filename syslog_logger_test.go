@@ -0,0 +1,149 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyslogLoggerRFC5424(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	logger, err := newSyslogLogger(&Config{
+		LogSyslogFormat:   "rfc5424",
+		LogSyslogNetwork:  "udp",
+		LogSyslogAddress:  conn.LocalAddr().String(),
+		LogSyslogFacility: "local3",
+		LogSyslogAppName:  "app",
+		LogSyslogProcID:   "1234",
+	})
+	require.Nil(t, err)
+	logger.hostname = "host"
+
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 123456000, time.UTC)
+	require.Nil(t, logger.Log(timestamp, LevelWarning, LogFields{"b": `x"y]z\`, "a": 1, "bad key=": true}, "test 1234"))
+
+	buffer := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buffer)
+	require.Nil(t, err)
+
+	// local3 (19) * 8 + warning (4)
+	assert.Equal(t, `<156>1 2017-08-28T17:04:41.123456Z host app 1234 - [fields@32473 a="1" b="x\"y\]z\\" bad_key_="true"] test 1234`, string(buffer[:n]))
+}
+
+func TestSyslogLoggerRFC5424NoFields(t *testing.T) {
+	logger := &syslogLogger{facility: 1, hostname: "host", appName: "app", procID: "1", sdID: "custom@1"}
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC)
+
+	assert.Equal(t, "<11>1 2017-08-28T17:04:41.000000Z host app 1 - - test", logger.formatRFC5424(timestamp, LevelError, nil, "test"))
+	assert.Equal(t, `<11>1 2017-08-28T17:04:41.000000Z host app 1 - [custom@1 a="b"] test`, logger.formatRFC5424(timestamp, LevelError, LogFields{"a": "b"}, "test"))
+}
+
+func TestSyslogLoggerRFC3164(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer listener.Close()
+
+	logger, err := newSyslogLogger(&Config{
+		LogSyslogFormat:   "rfc3164",
+		LogSyslogNetwork:  "tcp",
+		LogSyslogAddress:  listener.Addr().String(),
+		LogSyslogFacility: "daemon",
+		LogSyslogAppName:  "app",
+		LogSyslogProcID:   "1234",
+	})
+	require.Nil(t, err)
+	logger.hostname = "host"
+
+	conn, err := listener.Accept()
+	require.Nil(t, err)
+	defer conn.Close()
+
+	timestamp := time.Date(2017, 8, 8, 17, 4, 41, 0, time.UTC)
+	require.Nil(t, logger.Log(timestamp, LevelInfo, LogFields{"b": 2, "a": "x"}, "test 1234"))
+
+	// daemon (3) * 8 + informational (6)
+	expected := "<30>Aug  8 17:04:41 host app[1234]: test 1234 a=x b=2"
+
+	reader := bufio.NewReader(conn)
+	length, err := reader.ReadString(' ')
+	require.Nil(t, err)
+	assert.Equal(t, strconv.Itoa(len(expected))+" ", length)
+
+	frame := make([]byte, len(expected))
+	_, err = reader.Read(frame)
+	require.Nil(t, err)
+	assert.Equal(t, expected, string(frame))
+}
+
+func TestSyslogLoggerUnixSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "syslog")
+	require.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	address := filepath.Join(dir, "log")
+	conn, err := net.ListenPacket("unixgram", address)
+	require.Nil(t, err)
+	defer conn.Close()
+
+	logger, err := newSyslogLogger(&Config{
+		LogSyslogFormat:   "rfc3164",
+		LogSyslogAddress:  address,
+		LogSyslogFacility: "user",
+		LogSyslogAppName:  "app",
+		LogSyslogProcID:   "1234",
+	})
+	require.Nil(t, err)
+	assert.Equal(t, "unixgram", logger.network)
+
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC)
+	require.Nil(t, logger.Log(timestamp, LevelDebug, nil, "test 1234"))
+
+	buffer := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buffer)
+	require.Nil(t, err)
+
+	// Hostname is omitted for local sockets
+	assert.Equal(t, "<15>Aug 28 17:04:41 app[1234]: test 1234", string(buffer[:n]))
+}
+
+func TestSyslogLoggerSeverities(t *testing.T) {
+	logger := &syslogLogger{facility: 0}
+	assert.Equal(t, 2, logger.priority(LevelFatal))
+	assert.Equal(t, 2, logger.priority(LevelPanic))
+	assert.Equal(t, 3, logger.priority(LevelError))
+	assert.Equal(t, 4, logger.priority(LevelWarning))
+	assert.Equal(t, 6, logger.priority(LevelInfo))
+	assert.Equal(t, 7, logger.priority(LevelDebug))
+}
+
+func TestSyslogLoggerConnectionError(t *testing.T) {
+	_, err := newSyslogLogger(&Config{
+		LogSyslogFormat:  "rfc5424",
+		LogSyslogAddress: filepath.Join(os.TempDir(), "missing-syslog-socket"),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestSyslogLoggerFacility(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.Nil(t, err)
+	defer conn.Close()
+
+	logger, err := newSyslogLogger(&Config{LogSyslogNetwork: "udp", LogSyslogAddress: conn.LocalAddr().String()})
+	require.Nil(t, err)
+	defer logger.Close()
+	assert.Equal(t, 1, logger.facility)
+
+	_, err = newSyslogLogger(&Config{LogSyslogNetwork: "udp", LogSyslogAddress: conn.LocalAddr().String(), LogSyslogFacility: "local8"})
+	assert.Equal(t, ErrIllegalSyslogFacility, err)
+}
@@ -0,0 +1,228 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/derision-test/glock"
+)
+
+type (
+	// TestingT is the subset of testing.TB used by a testing logger.
+	TestingT interface {
+		Helper()
+		Log(args ...interface{})
+		Errorf(format string, args ...interface{})
+		FailNow()
+	}
+
+	// TestingLoggerConfigFunc is a function used to configure a testing logger.
+	TestingLoggerConfigFunc func(*testingLoggerOptions)
+
+	testingLoggerOptions struct {
+		clock         glock.Clock
+		level         LogLevel
+		failOnError   bool
+		allowedErrors []string
+	}
+
+	testingLogger struct {
+		t        TestingT
+		options  *testingLoggerOptions
+		template *template.Template
+		fields   LogFields
+		depth    int
+		mutex    *sync.Mutex
+	}
+)
+
+var _ Logger = &testingLogger{}
+
+// NewTestingLogger creates a logger that writes each message via t.Log using the
+// console encoding without color. The test framework attributes each line to the
+// code that called the logger. Messages logged at the fatal level stop the test via
+// t.FailNow rather than exiting the process, and messages logged at the panic level
+// panic with the rendered message.
+func NewTestingLogger(t TestingT, configs ...TestingLoggerConfigFunc) Logger {
+	options := &testingLoggerOptions{
+		clock: glock.NewRealClock(),
+		level: LevelDebug,
+	}
+
+	for _, f := range configs {
+		f(options)
+	}
+
+	// Errors are impossible here as the template text is fixed
+	templates, _ := newConsoleTemplate(newTimestampFormat("", consoleTimeLayout(false)), true, false, nil)
+
+	return &testingLogger{
+		t:        t,
+		options:  options,
+		template: templates[LevelNone],
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// WithTestingClock sets the clock used to timestamp messages.
+func WithTestingClock(clock glock.Clock) TestingLoggerConfigFunc {
+	return func(o *testingLoggerOptions) { o.clock = clock }
+}
+
+// WithTestingLevel sets the maximum level of messages written to the test log.
+func WithTestingLevel(level LogLevel) TestingLoggerConfigFunc {
+	return func(o *testingLoggerOptions) { o.level = level }
+}
+
+// WithFailOnError causes the test to fail when a message is logged at the error,
+// panic, or fatal level, unless the rendered message contains one of the given substrings.
+func WithFailOnError(allowed ...string) TestingLoggerConfigFunc {
+	return func(o *testingLoggerOptions) {
+		o.failOnError = true
+		o.allowedErrors = append(o.allowedErrors, allowed...)
+	}
+}
+
+func (l *testingLogger) WithIndirectCaller(frames int) Logger {
+	if frames <= 0 {
+		panic("WithIndirectCaller called with invalid frame count")
+	}
+
+	clone := *l
+	clone.depth += frames
+	return &clone
+}
+
+func (l *testingLogger) WithFields(fields LogFields) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	clone := *l
+	clone.fields = l.fields.concat(fields)
+	return &clone
+}
+
+func (l *testingLogger) LogWithFields(level LogLevel, fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(level, fields, format, args...)
+}
+
+func (l *testingLogger) Sync() error {
+	return nil
+}
+
+func (l *testingLogger) Debug(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelDebug, nil, format, args...)
+}
+
+func (l *testingLogger) Info(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelInfo, nil, format, args...)
+}
+
+func (l *testingLogger) Warning(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelWarning, nil, format, args...)
+}
+
+func (l *testingLogger) Error(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelError, nil, format, args...)
+}
+
+func (l *testingLogger) Panic(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelPanic, nil, format, args...)
+}
+
+func (l *testingLogger) Fatal(format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelFatal, nil, format, args...)
+}
+
+func (l *testingLogger) DebugWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelDebug, fields, format, args...)
+}
+
+func (l *testingLogger) InfoWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelInfo, fields, format, args...)
+}
+
+func (l *testingLogger) WarningWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelWarning, fields, format, args...)
+}
+
+func (l *testingLogger) ErrorWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelError, fields, format, args...)
+}
+
+func (l *testingLogger) PanicWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelPanic, fields, format, args...)
+}
+
+func (l *testingLogger) FatalWithFields(fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+	l.log(LevelFatal, fields, format, args...)
+}
+
+func (l *testingLogger) log(level LogLevel, fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+
+	if level.Enabled(l.options.level) {
+		l.write(level, fields, format, args...)
+	}
+
+	switch level {
+	case LevelFatal:
+		l.t.FailNow()
+	case LevelPanic:
+		panic(fmt.Sprintf(format, args...))
+	}
+}
+
+func (l *testingLogger) write(level LogLevel, fields LogFields, format string, args ...interface{}) {
+	l.t.Helper()
+
+	fields = l.fields.concat(addCaller(fields.clone(), l.depth+2)).normalizeTimeValues()
+	message := fmt.Sprintf(format, args...)
+
+	buffer := bytes.Buffer{}
+	if err := l.template.Execute(&buffer, map[string]interface{}{
+		"timestamp": l.options.clock.Now().UTC(),
+		"level":     level,
+		"levelName": level.String(),
+		"message":   message,
+		"fields":    consoleFields(fields, sortedFieldKeys(fields)),
+	}); err != nil {
+		l.t.Errorf("failed to render log message: %s", err)
+		return
+	}
+
+	l.mutex.Lock()
+	l.t.Log(buffer.String())
+	l.mutex.Unlock()
+
+	if l.options.failOnError && level.Enabled(LevelError) && !l.isAllowedError(message) {
+		l.t.Errorf("unexpected %s logged: %s", level, message)
+	}
+}
+
+func (l *testingLogger) isAllowedError(message string) bool {
+	for _, allowed := range l.options.allowedErrors {
+		if strings.Contains(message, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,94 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeTestingT struct {
+	logs     []string
+	errors   []string
+	failures int
+}
+
+func (t *fakeTestingT) Helper() {}
+
+func (t *fakeTestingT) Log(args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func (t *fakeTestingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeTestingT) FailNow() {
+	t.failures++
+}
+
+func TestTestingLogger(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	clock := glock.NewMockClockAt(time.Date(2017, 8, 28, 17, 4, 41, 0, time.UTC))
+	logger := NewTestingLogger(fakeT, WithTestingClock(clock), WithTestingLevel(LevelInfo))
+
+	logger.WithFields(LogFields{"a": 1}).InfoWithFields(LogFields{"b": 2}, "foo %d", 12)
+	logger.Debug("filtered")
+
+	require.Len(t, fakeT.logs, 1)
+
+	// Note: this value refers to the line number containing `InfoWithFields` in the
+	// test setup above. If code is added before that line, this value must be updated.
+	assert.Equal(t, "[I] [2017/08/28 17:04:41.000] foo 12 a=1 b=2 caller=log/testing_logger_test.go:38", fakeT.logs[0])
+	assert.Empty(t, fakeT.errors)
+	assert.Zero(t, fakeT.failures)
+}
+
+func TestTestingLoggerFailOnError(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	logger := NewTestingLogger(fakeT, WithFailOnError("connection refused"))
+
+	logger.Error("dial failed: connection refused")
+	assert.Empty(t, fakeT.errors)
+
+	logger.Error("dial failed: timeout")
+	assert.Equal(t, []string{"unexpected error logged: dial failed: timeout"}, fakeT.errors)
+	assert.Len(t, fakeT.logs, 2)
+}
+
+func TestTestingLoggerFatal(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	logger := NewTestingLogger(fakeT)
+
+	logger.Error("failure")
+	assert.Empty(t, fakeT.errors)
+	assert.Zero(t, fakeT.failures)
+
+	logger.Fatal("fatal failure")
+	assert.Equal(t, 1, fakeT.failures)
+}
+
+func TestTestingLoggerWithRealT(t *testing.T) {
+	logger := NewTestingLogger(t, WithFailOnError())
+	logger.Info("shown only for failing tests or in verbose mode")
+}
+
+func TestTestingLoggerPanic(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	logger := NewTestingLogger(fakeT)
+
+	assert.PanicsWithValue(t, "oops", func() { Panic(logger, "oops") })
+	assert.Len(t, fakeT.logs, 1)
+	assert.Zero(t, fakeT.failures)
+}
+
+func TestTestingLoggerPanicFiltered(t *testing.T) {
+	fakeT := &fakeTestingT{}
+	logger := NewTestingLogger(fakeT, WithTestingLevel(LevelFatal))
+
+	assert.PanicsWithValue(t, "oops", func() { Panic(logger, "oops") })
+	assert.Empty(t, fakeT.logs)
+}
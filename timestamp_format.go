@@ -0,0 +1,80 @@
+package log
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timestampFormat formats the timestamps written by text encodings. Timestamps are
+// written either as a string in a time layout or as an integer number of seconds,
+// milliseconds, or nanoseconds since the Unix epoch.
+type timestampFormat struct {
+	layout string
+	unit   time.Duration
+}
+
+// newTimestampFormat returns the format with the given name. The names rfc3339,
+// rfc3339nano, unix, unixmilli, and unixnano are recognized regardless of case. An
+// empty name selects the given default layout, and any other name is used as a
+// custom time layout.
+func newTimestampFormat(name, defaultLayout string) timestampFormat {
+	switch strings.ToLower(name) {
+	case "":
+		return timestampFormat{layout: defaultLayout}
+	case "rfc3339":
+		return timestampFormat{layout: time.RFC3339}
+	case "rfc3339nano":
+		return timestampFormat{layout: time.RFC3339Nano}
+	case "unix":
+		return timestampFormat{unit: time.Second}
+	case "unixmilli":
+		return timestampFormat{unit: time.Millisecond}
+	case "unixnano":
+		return timestampFormat{unit: time.Nanosecond}
+	}
+
+	return timestampFormat{layout: name}
+}
+
+// value returns the formatted timestamp as an int64 for the unix formats and as a
+// string otherwise.
+func (f timestampFormat) value(t time.Time) interface{} {
+	switch f.unit {
+	case time.Second:
+		return t.Unix()
+	case time.Millisecond:
+		return t.UnixMilli()
+	case time.Nanosecond:
+		return t.UnixNano()
+	}
+
+	return t.Format(f.layout)
+}
+
+// format returns the formatted timestamp as a string.
+func (f timestampFormat) format(t time.Time) string {
+	if v, ok := f.value(t).(int64); ok {
+		return strconv.FormatInt(v, 10)
+	}
+
+	return t.Format(f.layout)
+}
+
+// loadTimezone returns the location with the given name. The names UTC and Local
+// are recognized regardless of case, and an empty name selects UTC.
+func loadTimezone(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	}
+
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrIllegalTimezone
+	}
+
+	return location, nil
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/derision-test/glock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimestampFormat(t *testing.T) {
+	timestamp := time.Date(2017, 8, 28, 17, 4, 41, 123456789, time.UTC)
+
+	testCases := []struct {
+		name     string
+		expected interface{}
+	}{
+		{"", "2017-08-28T17:04:41.123+0000"},
+		{"RFC3339", "2017-08-28T17:04:41Z"},
+		{"rfc3339nano", "2017-08-28T17:04:41.123456789Z"},
+		{"unix", int64(1503939881)},
+		{"unixmilli", int64(1503939881123)},
+		{"unixnano", int64(1503939881123456789)},
+		{"Jan 2 15:04:05.000000", "Aug 28 17:04:41.123456"},
+	}
+
+	for _, testCase := range testCases {
+		format := newTimestampFormat(testCase.name, JSONTimeFormat)
+		assert.Equal(t, testCase.expected, format.value(timestamp))
+	}
+
+	assert.Equal(t, "1503939881123", newTimestampFormat("unixmilli", "").format(timestamp))
+}
+
+func TestLoadTimezone(t *testing.T) {
+	location, err := loadTimezone("")
+	require.Nil(t, err)
+	assert.Equal(t, time.UTC, location)
+
+	location, err = loadTimezone("Local")
+	require.Nil(t, err)
+	assert.Equal(t, time.Local, location)
+
+	location, err = loadTimezone("Asia/Tokyo")
+	require.Nil(t, err)
+	assert.Equal(t, "Asia/Tokyo", location.String())
+
+	_, err = loadTimezone("Nowhere")
+	assert.Equal(t, ErrIllegalTimezone, err)
+}
+
+func TestInitLoggerTimestampFormat(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	started := time.Unix(1503930000, 0)
+
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "json", LogTimestampFormat: "rfc3339", LogTimezone: "Asia/Tokyo", LogFormatTimeFields: true},
+		WithOutput(buffer),
+		WithClock(clock),
+	)
+	require.Nil(t, err)
+	logger.InfoWithFields(LogFields{"started": started}, "test 1234")
+
+	data := LogFields{}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &data))
+	assert.Equal(t, "2017-08-29T02:04:41+09:00", data["timestamp"])
+	assert.Equal(t, "2017-08-28T23:20:00+09:00", data["started"])
+}
+
+func TestInitLoggerTimestampFormatUnix(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+	started := time.Unix(1503930000, 0)
+
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "json", LogTimestampFormat: "unixmilli"},
+		WithOutput(buffer),
+		WithClock(clock),
+	)
+	require.Nil(t, err)
+	logger.InfoWithFields(LogFields{"started": started}, "test 1234")
+
+	data := LogFields{}
+	require.Nil(t, json.Unmarshal(buffer.Bytes(), &data))
+	assert.Equal(t, float64(1503939881000), data["timestamp"])
+
+	// Time values in fields keep the default format unless LogFormatTimeFields is set
+	assert.Equal(t, started.Format(JSONTimeFormat), data["started"])
+}
+
+func TestInitLoggerTimestampFormatConsole(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	clock := glock.NewMockClockAt(time.Unix(1503939881, 0))
+
+	logger, err := InitLogger(
+		&Config{LogLevel: "info", LogEncoding: "console", LogTimestampFormat: "rfc3339", LogTimezone: "Asia/Tokyo"},
+		WithOutput(buffer),
+		WithClock(clock),
+	)
+	require.Nil(t, err)
+	logger.Info("test 1234")
+
+	assert.True(t, strings.Contains(buffer.String(), "[2017-08-29T02:04:41+09:00] test 1234"))
+}
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// textValue formats a field value for text encodings. Replay bundles are written
+// as JSON rather than in the Go syntax for slices of maps.
+func textValue(value interface{}) string {
+	if bundle, ok := value.([]LogFields); ok {
+		if serialized, err := json.Marshal(bundle); err == nil {
+			return string(serialized)
+		}
+	}
+
+	return fmt.Sprintf("%v", value)
+}
+
+// nativeValue converts values of named basic types, slices, arrays, and maps with
+// string keys into bool, int64, uint64, float64, string, []interface{}, or
+// map[string]interface{}. All other values are round-tripped through encoding/json,
+// in which case numbers are represented as json.Number. Nil pointers, slices, and
+// interfaces are converted to nil.
+func nativeValue(value interface{}) interface{} {
+	rv := reflect.ValueOf(value)
+
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint()
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	case reflect.String:
+		return rv.String()
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+
+		values := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, rv.Index(i).Interface())
+		}
+		return values
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := make(map[string]interface{}, rv.Len())
+			for _, key := range rv.MapKeys() {
+				m[key.String()] = rv.MapIndex(key).Interface()
+			}
+			return m
+		}
+	}
+
+	serialized, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	var decoded interface{}
+	decoder := json.NewDecoder(bytes.NewReader(serialized))
+	decoder.UseNumber()
+	if err := decoder.Decode(&decoded); err != nil {
+		return string(serialized)
+	}
+
+	return decoded
+}
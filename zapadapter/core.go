@@ -0,0 +1,78 @@
+package zapadapter
+
+import (
+	"github.com/go-nacelle/log/v2"
+	"go.uber.org/zap/zapcore"
+)
+
+type core struct {
+	logger log.Logger
+}
+
+var _ zapcore.Core = &core{}
+
+// NewCore creates a zapcore.Core that forwards each entry to the given logger.
+// Level filtering is left to the nacelle logger. DPanic, panic, and fatal-level
+// entries are logged at the error level, and zap remains responsible for panicking
+// or exiting afterwards.
+func NewCore(logger log.Logger) zapcore.Core {
+	return &core{logger: logger}
+}
+
+func (c *core) Enabled(zapcore.Level) bool {
+	return true
+}
+
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	if len(fields) == 0 {
+		return c
+	}
+
+	return &core{logger: c.logger.WithFields(fromZapFields(fields))}
+}
+
+func (c *core) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	return ce.AddCore(entry, c)
+}
+
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	logFields := fromZapFields(fields)
+	if entry.LoggerName != "" {
+		logFields["logger"] = entry.LoggerName
+	}
+	if entry.Caller.Defined {
+		logFields["caller"] = entry.Caller.TrimmedPath()
+	}
+	if entry.Stack != "" {
+		logFields["stack"] = entry.Stack
+	}
+
+	c.logger.LogWithFields(fromZapLevel(entry.Level), logFields, "%s", entry.Message)
+	return nil
+}
+
+func (c *core) Sync() error {
+	return c.logger.Sync()
+}
+
+func fromZapLevel(level zapcore.Level) log.LogLevel {
+	switch level {
+	case zapcore.DebugLevel:
+		return log.LevelDebug
+	case zapcore.InfoLevel:
+		return log.LevelInfo
+	case zapcore.WarnLevel:
+		return log.LevelWarning
+	}
+
+	return log.LevelError
+}
+
+func fromZapFields(fields []zapcore.Field) log.LogFields {
+	encoder := zapcore.NewMapObjectEncoder()
+	for _, field := range fields {
+		field.AddTo(encoder)
+	}
+
+	return log.LogFields(encoder.Fields)
+}
@@ -0,0 +1,70 @@
+// Package zapadapter converts between zap loggers and nacelle loggers, allowing
+// services to migrate between the two incrementally.
+package zapadapter
+
+import (
+	"fmt"
+
+	"github.com/go-nacelle/log/v2"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type zapLogger struct {
+	logger *zap.Logger
+}
+
+var _ log.MinimalLogger = &zapLogger{}
+
+// FromZap wraps the given zap logger as a MinimalLogger. Messages logged at the
+// panic and fatal levels are written with zap's panic and fatal levels, which panic
+// or terminate the process according to the zap logger's configuration.
+func FromZap(logger *zap.Logger) log.MinimalLogger {
+	return &zapLogger{logger: logger}
+}
+
+func (l *zapLogger) WithFields(fields log.LogFields) log.MinimalLogger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	return &zapLogger{logger: l.logger.With(toZapFields(fields)...)}
+}
+
+func (l *zapLogger) LogWithFields(level log.LogLevel, fields log.LogFields, format string, args ...interface{}) {
+	if ce := l.logger.Check(toZapLevel(level), fmt.Sprintf(format, args...)); ce != nil {
+		ce.Write(toZapFields(fields)...)
+	}
+}
+
+func (l *zapLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+func toZapLevel(level log.LogLevel) zapcore.Level {
+	switch level {
+	case log.LevelDebug:
+		return zapcore.DebugLevel
+	case log.LevelInfo:
+		return zapcore.InfoLevel
+	case log.LevelWarning:
+		return zapcore.WarnLevel
+	case log.LevelError:
+		return zapcore.ErrorLevel
+	case log.LevelPanic:
+		return zapcore.PanicLevel
+	case log.LevelFatal:
+		return zapcore.FatalLevel
+	}
+
+	return zapcore.InfoLevel
+}
+
+func toZapFields(fields log.LogFields) []zap.Field {
+	zapFields := make([]zap.Field, 0, len(fields))
+	for key, value := range fields {
+		zapFields = append(zapFields, zap.Any(key, value))
+	}
+
+	return zapFields
+}
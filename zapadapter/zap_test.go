@@ -0,0 +1,69 @@
+package zapadapter
+
+import (
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/go-nacelle/log/v2/logtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestFromZap(t *testing.T) {
+	observed, logs := observer.New(zapcore.InfoLevel)
+	logger := log.FromMinimalLogger(FromZap(zap.New(observed)))
+
+	logger.WithFields(log.LogFields{"a": 1}).InfoWithFields(log.LogFields{"b": "two"}, "foo %d", 12)
+	logger.Debug("filtered")
+	logger.Warning("bar")
+	logger.Error("baz")
+
+	entries := logs.AllUntimed()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, "foo 12", entries[0].Message)
+	assert.Equal(t, int64(1), entries[0].ContextMap()["a"])
+	assert.Equal(t, "two", entries[0].ContextMap()["b"])
+	// Note: this value refers to the line number containing the first log call in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "zapadapter/zap_test.go:19", entries[0].ContextMap()["caller"])
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+}
+
+func TestNewCore(t *testing.T) {
+	logger := logtest.New()
+	zapLogger := zap.New(NewCore(logger), zap.AddCaller()).Named("worker").With(zap.Int("a", 1))
+
+	zapLogger.Info("foo", zap.String("b", "two"))
+	zapLogger.Debug("bar")
+	zapLogger.Warn("baz")
+	zapLogger.Error("bonk")
+
+	logger.AssertLoggedInOrder(t,
+		logtest.Expect(log.LevelInfo, "foo", log.LogFields{"a": int64(1), "b": "two", "logger": "worker"}),
+		logtest.Expect(log.LevelDebug, "bar"),
+		logtest.Expect(log.LevelWarning, "baz"),
+		logtest.Expect(log.LevelError, "bonk"),
+	)
+
+	messages := logger.Messages()
+	require.Len(t, messages, 4)
+	// Note: this value refers to the line number containing the first log call in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "zapadapter/zap_test.go:43", messages[0].Caller)
+}
+
+func TestNewCorePanic(t *testing.T) {
+	logger := logtest.New()
+	zapLogger := zap.New(NewCore(logger))
+
+	assert.Panics(t, func() { zapLogger.Panic("boom") })
+	logger.AssertLogged(t, log.LevelError, "boom")
+}
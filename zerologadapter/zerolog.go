@@ -0,0 +1,66 @@
+// Package zerologadapter wraps zerolog loggers as nacelle loggers, allowing
+// services to migrate between the two incrementally.
+package zerologadapter
+
+import (
+	"github.com/go-nacelle/log/v2"
+	"github.com/rs/zerolog"
+)
+
+type zerologLogger struct {
+	logger zerolog.Logger
+}
+
+var _ log.MinimalLogger = &zerologLogger{}
+
+// FromZerolog wraps the given zerolog logger as a MinimalLogger. Messages logged
+// at the panic and fatal levels are written with zerolog's Panic and Fatal methods,
+// which panic or terminate the process.
+func FromZerolog(logger zerolog.Logger) log.MinimalLogger {
+	return &zerologLogger{logger: logger}
+}
+
+func (l *zerologLogger) WithFields(fields log.LogFields) log.MinimalLogger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	return &zerologLogger{logger: l.logger.With().Fields(map[string]interface{}(fields)).Logger()}
+}
+
+func (l *zerologLogger) LogWithFields(level log.LogLevel, fields log.LogFields, format string, args ...interface{}) {
+	var event *zerolog.Event
+	switch level {
+	case log.LevelFatal:
+		event = l.logger.Fatal()
+	case log.LevelPanic:
+		event = l.logger.Panic()
+	default:
+		event = l.logger.WithLevel(toZerologLevel(level))
+	}
+
+	event.Fields(map[string]interface{}(fields)).Msgf(format, args...)
+}
+
+func (l *zerologLogger) Sync() error {
+	return nil
+}
+
+func toZerologLevel(level log.LogLevel) zerolog.Level {
+	switch level {
+	case log.LevelDebug:
+		return zerolog.DebugLevel
+	case log.LevelInfo:
+		return zerolog.InfoLevel
+	case log.LevelWarning:
+		return zerolog.WarnLevel
+	case log.LevelError:
+		return zerolog.ErrorLevel
+	case log.LevelPanic:
+		return zerolog.PanicLevel
+	case log.LevelFatal:
+		return zerolog.FatalLevel
+	}
+
+	return zerolog.InfoLevel
+}
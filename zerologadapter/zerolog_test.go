@@ -0,0 +1,44 @@
+package zerologadapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-nacelle/log/v2"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromZerolog(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := log.FromMinimalLogger(FromZerolog(zerolog.New(buffer).Level(zerolog.InfoLevel)))
+
+	logger.WithFields(log.LogFields{"a": 1}).InfoWithFields(log.LogFields{"b": "two"}, "foo %d", 12)
+	logger.Debug("filtered")
+	logger.Warning("bar")
+	logger.Error("baz")
+
+	lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+	require.Len(t, lines, 3)
+
+	var entries []map[string]interface{}
+	for _, line := range lines {
+		entry := map[string]interface{}{}
+		require.Nil(t, json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+
+	assert.Equal(t, "info", entries[0]["level"])
+	assert.Equal(t, "foo 12", entries[0]["message"])
+	assert.Equal(t, float64(1), entries[0]["a"])
+	assert.Equal(t, "two", entries[0]["b"])
+	// Note: this value refers to the line number containing the first log call in
+	// the test setup above. If code is added before that line, this value must be
+	// updated.
+	assert.Equal(t, "zerologadapter/zerolog_test.go:19", entries[0]["caller"])
+	assert.Equal(t, "warn", entries[1]["level"])
+	assert.Equal(t, "error", entries[2]["level"])
+}